@@ -1,6 +1,10 @@
 // Package avg provides types for computing running averages.
 package avg
 
+import (
+	"math"
+)
+
 // The Averager interface describe a type which maintains
 // a running average.
 type Averager interface {
@@ -10,26 +14,93 @@ type Averager interface {
 
 // A MovingAverage computes an average of the last
 // Size samples.
+//
+// The samples are kept in a ring buffer, so Update
+// takes constant time, and allocates only when Size
+// changes. The sum is kept with compensated summation,
+// and recomputed each time the buffer wraps around,
+// so it doesn't drift over many updates. A Size less
+// than 1 is treated as 1.
 type MovingAverage struct {
 	Size    int
-	samples []float64
-	sum     float64
+	samples ring
+	sum     sum
 }
 
 // Update adds the given sample to the average
 // dropping the oldest one.
 func (ma *MovingAverage) Update(value float64) {
-	ma.samples = append(ma.samples, value)
-	ma.sum += value
-	for len(ma.samples) > ma.Size {
-		ma.sum -= ma.samples[0]
-		ma.samples = ma.samples[1:]
+	if len(ma.samples.buf) != ma.size() {
+		ma.resize()
+	}
+
+	old, full := ma.samples.push(value)
+	switch {
+	case !full:
+		ma.sum.add(value)
+	case ma.samples.head == 0:
+		ma.resum()
+	default:
+		ma.sum.add(-old)
+		ma.sum.add(value)
+	}
+}
+
+func (ma *MovingAverage) size() int {
+	if ma.Size < 1 {
+		return 1
+	}
+	return ma.Size
+}
+
+// resize moves the samples to a ring buffer of the
+// current Size, keeping the newest ones.
+func (ma *MovingAverage) resize() {
+	size := ma.size()
+	old := ma.samples
+	ma.samples = newRing(size)
+	skip := old.n - size
+	for i := 0; i < old.n; i++ {
+		if i >= skip {
+			ma.samples.push(old.at(i))
+		}
+	}
+	ma.resum()
+}
+
+// resum recomputes the sum from the samples.
+func (ma *MovingAverage) resum() {
+	ma.sum = sum{}
+	for i := 0; i < ma.samples.n; i++ {
+		ma.sum.add(ma.samples.at(i))
 	}
 }
 
 // Average Computes the current average.
 func (ma *MovingAverage) Average() float64 {
-	return ma.sum / float64(len(ma.samples))
+	return ma.sum.value() / float64(ma.samples.n)
+}
+
+// A sum adds up values using Neumaier's variant of
+// Kahan summation, which keeps the rounding error
+// lost by each addition in a separate compensation
+// term.
+type sum struct {
+	total, compensation float64
+}
+
+func (s *sum) add(value float64) {
+	t := s.total + value
+	if math.Abs(s.total) >= math.Abs(value) {
+		s.compensation += (s.total - t) + value
+	} else {
+		s.compensation += (value - t) + s.total
+	}
+	s.total = t
+}
+
+func (s *sum) value() float64 {
+	return s.total + s.compensation
 }
 
 // An AlphaAverage computes a running average
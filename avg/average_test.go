@@ -1,12 +1,93 @@
 package avg
 
 import (
+	"math"
 	. "testing"
 )
 
+func TestMovingAverage(t *T) {
+	a := MovingAverage{Size: 3}
+	for i, expect := range []float64{1, 1.5, 2, 3, 4} {
+		a.Update(float64(i + 1))
+		if avg := a.Average(); avg != expect {
+			t.Errorf("update %d: expected %v, got %v", i, expect, avg)
+		}
+	}
+
+	// Changing Size keeps the newest samples.
+	a.Size = 2
+	a.Update(6)
+	if avg := a.Average(); avg != 5.5 {
+		t.Errorf("after shrinking: expected 5.5, got %v", avg)
+	}
+	a.Size = 4
+	a.Update(7)
+	if avg := a.Average(); avg != 6 {
+		t.Errorf("after growing: expected 6, got %v", avg)
+	}
+}
+
+func TestMovingAverageDrift(t *T) {
+	// Large samples followed by small ones lose the small
+	// ones' precision from a plain running sum. The window
+	// is filled, and then all but one of its samples are
+	// replaced, so it never wraps around to be recomputed.
+	const size = 1000
+	a := MovingAverage{Size: size}
+	for i := 0; i < 2*size-1; i++ {
+		v := 0.1
+		if i < 10 {
+			v = 1e15
+		}
+		a.Update(v)
+	}
+	if avg := a.Average(); math.Abs(avg-0.1) > 1e-12 {
+		t.Errorf("expected 0.1, got %v", avg)
+	}
+}
+
+func TestSum(t *T) {
+	var s sum
+	naive := 0.0
+	for _, v := range []float64{1, 1e100, 1, -1e100} {
+		s.add(v)
+		naive += v
+	}
+	if naive == 2 {
+		t.Fatal("expected a plain running sum to lose the small values")
+	}
+	if total := s.value(); total != 2 {
+		t.Errorf("expected 2, got %v", total)
+	}
+}
+
+func TestMovingAverageAllocs(t *T) {
+	a := MovingAverage{Size: 64}
+	a.Update(0)
+
+	allocs := AllocsPerRun(1000, func() {
+		a.Update(1)
+		a.Average()
+	})
+	if allocs != 0 {
+		t.Errorf("expected no allocations per Update, got %v", allocs)
+	}
+}
+
 func BenchmarkRolling(b *B) {
 	a := MovingAverage{Size: 16}
 
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		a.Update(float64(i))
+		a.Average()
+	}
+}
+
+func BenchmarkRolling1024(b *B) {
+	a := MovingAverage{Size: 1024}
+
+	b.ReportAllocs()
 	for i := 0; i < b.N; i++ {
 		a.Update(float64(i))
 		a.Average()
@@ -0,0 +1,36 @@
+package avg
+
+// A ring holds the last len(buf) values pushed into it.
+type ring struct {
+	buf     []float64
+	head, n int
+}
+
+func newRing(size int) ring {
+	return ring{buf: make([]float64, size)}
+}
+
+// at returns the ith oldest value in the ring.
+func (r *ring) at(i int) float64 {
+	return r.buf[(r.head+i)%len(r.buf)]
+}
+
+// push adds value to the ring, returning the value it replaces, if the
+// ring was full.
+func (r *ring) push(value float64) (old float64, full bool) {
+	i := r.head + r.n
+	if i >= len(r.buf) {
+		i -= len(r.buf)
+	}
+	if r.n == len(r.buf) {
+		old, full = r.buf[r.head], true
+		r.head++
+		if r.head == len(r.buf) {
+			r.head = 0
+		}
+	} else {
+		r.n++
+	}
+	r.buf[i] = value
+	return old, full
+}
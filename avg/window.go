@@ -0,0 +1,185 @@
+package avg
+
+import (
+	"math"
+)
+
+// A WindowStats computes statistics over the last Size samples. Each
+// Update takes constant amortized time, whatever the Size, and no
+// memory is allocated after the first. Its Average
+// is the mean of the samples, so it is also an Averager.
+//
+// Size must be set before the first Update, and not changed after it.
+// A Size less than 1 is treated as 1.
+type WindowStats struct {
+	Size int
+
+	samples  ring
+	seq      uint64 // Number of samples ever added
+	mean, m2 float64
+	min, max deque
+}
+
+// Update adds the given sample, dropping the oldest one once there are
+// Size samples.
+func (ws *WindowStats) Update(value float64) {
+	if ws.samples.buf == nil {
+		size := ws.Size
+		if size < 1 {
+			size = 1
+		}
+		ws.samples = newRing(size)
+		ws.min = newDeque(size)
+		ws.max = newDeque(size)
+	}
+
+	if old, full := ws.samples.push(value); !full {
+		ws.add(value)
+	} else if ws.samples.head == 0 {
+		ws.resum()
+	} else {
+		ws.replace(old, value)
+	}
+
+	ws.seq++
+	oldest := ws.seq - uint64(ws.samples.n) + 1
+	ws.min.expire(oldest)
+	ws.max.expire(oldest)
+	ws.min.push(ws.seq, value, func(kept, value float64) bool { return kept < value })
+	ws.max.push(ws.seq, value, func(kept, value float64) bool { return kept > value })
+}
+
+// add, replace and resum maintain the mean and the sum of squared
+// differences from it. add and replace are Welford's method, which is
+// accurate over many updates, but still drifts, so each time the ring
+// wraps around, resum computes them afresh from the samples.
+func (ws *WindowStats) add(value float64) {
+	n := float64(ws.samples.n)
+	delta := value - ws.mean
+	ws.mean += delta / n
+	ws.m2 += delta * (value - ws.mean)
+}
+
+func (ws *WindowStats) replace(old, value float64) {
+	n := float64(ws.samples.n)
+	mean := ws.mean
+	ws.mean += (value - old) / n
+	ws.m2 += (value - old) * (value - ws.mean + old - mean)
+}
+
+func (ws *WindowStats) resum() {
+	ws.mean, ws.m2 = 0, 0
+	for _, v := range ws.samples.buf {
+		ws.mean += v
+	}
+	ws.mean /= float64(len(ws.samples.buf))
+	for _, v := range ws.samples.buf {
+		ws.m2 += (v - ws.mean) * (v - ws.mean)
+	}
+}
+
+// Count returns the number of samples in the window.
+func (ws *WindowStats) Count() int {
+	return ws.samples.n
+}
+
+// Average returns the mean of the samples in the window, or NaN if
+// there are none.
+func (ws *WindowStats) Average() float64 {
+	return ws.Mean()
+}
+
+// Mean returns the mean of the samples in the window, or NaN if there
+// are none.
+func (ws *WindowStats) Mean() float64 {
+	if ws.samples.n == 0 {
+		return math.NaN()
+	}
+	return ws.mean
+}
+
+// Variance returns the population variance of the samples in the
+// window, or NaN if there are none.
+func (ws *WindowStats) Variance() float64 {
+	if ws.samples.n == 0 {
+		return math.NaN()
+	}
+	// Rounding can leave m2 slightly negative when all samples are equal.
+	return math.Max(ws.m2, 0) / float64(ws.samples.n)
+}
+
+// StdDev returns the population standard deviation of the samples in
+// the window, or NaN if there are none.
+func (ws *WindowStats) StdDev() float64 {
+	return math.Sqrt(ws.Variance())
+}
+
+// Min returns the smallest sample in the window, or NaN if there are
+// none.
+func (ws *WindowStats) Min() float64 {
+	if ws.min.n == 0 {
+		return math.NaN()
+	}
+	return ws.min.front().value
+}
+
+// Max returns the largest sample in the window, or NaN if there are
+// none.
+func (ws *WindowStats) Max() float64 {
+	if ws.max.n == 0 {
+		return math.NaN()
+	}
+	return ws.max.front().value
+}
+
+// A deque is a fixed size double ended queue of samples, used to track
+// the minimum or maximum of a window. Its values are kept in order, so
+// the front is always the extreme value.
+type deque struct {
+	buf     []dequeEntry
+	head, n int
+}
+
+type dequeEntry struct {
+	seq   uint64
+	value float64
+}
+
+func newDeque(size int) deque {
+	return deque{buf: make([]dequeEntry, size)}
+}
+
+func (d *deque) at(i int) *dequeEntry {
+	i += d.head
+	if i >= len(d.buf) {
+		i -= len(d.buf)
+	}
+	return &d.buf[i]
+}
+
+func (d *deque) front() *dequeEntry {
+	return d.at(0)
+}
+
+// push adds a sample at the back, first dropping the samples from the
+// back which can never be the extreme value again, as keep(kept, value)
+// is false for them. There must be room for the sample once they are
+// dropped.
+func (d *deque) push(seq uint64, value float64, keep func(kept, value float64) bool) {
+	for d.n > 0 && !keep(d.at(d.n-1).value, value) {
+		d.n--
+	}
+	*d.at(d.n) = dequeEntry{seq, value}
+	d.n++
+}
+
+// expire drops the samples from the front older than oldest.
+func (d *deque) expire(oldest uint64) {
+	for d.n > 0 && d.front().seq < oldest {
+		d.head++
+		if d.head == len(d.buf) {
+			d.head = 0
+		}
+		d.n--
+	}
+}
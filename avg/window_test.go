@@ -0,0 +1,93 @@
+package avg
+
+import (
+	"math"
+	"math/rand"
+	. "testing"
+)
+
+// Check WindowStats satisfies Averager
+var _ Averager = new(WindowStats)
+
+// naiveStats computes the statistics of the last size values directly.
+func naiveStats(values []float64, size int) (mean, variance, min, max float64) {
+	if len(values) > size {
+		values = values[len(values)-size:]
+	}
+	min, max = math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		mean += v
+		min = math.Min(min, v)
+		max = math.Max(max, v)
+	}
+	mean /= float64(len(values))
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	variance /= float64(len(values))
+	return
+}
+
+func closeTo(a, b float64) bool {
+	return math.Abs(a-b) <= 1e-9*math.Max(1, math.Abs(b))
+}
+
+func TestWindowStats(t *T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, size := range []int{1, 2, 5, 64} {
+		ws := WindowStats{Size: size}
+		var values []float64
+		for i := 0; i < 1000; i++ {
+			v := rng.NormFloat64()*100 + 50
+			if i%7 == 0 {
+				v = math.Floor(v) // Repeat some values
+			}
+			values = append(values, v)
+			ws.Update(v)
+
+			mean, variance, min, max := naiveStats(values, size)
+			count := len(values)
+			if count > size {
+				count = size
+			}
+			if ws.Count() != count {
+				t.Fatalf("size %d, update %d: expected count %d, got %d", size, i, count, ws.Count())
+			}
+			if !closeTo(ws.Mean(), mean) || ws.Average() != ws.Mean() {
+				t.Fatalf("size %d, update %d: expected mean %v, got %v", size, i, mean, ws.Mean())
+			}
+			if !closeTo(ws.Variance(), variance) || !closeTo(ws.StdDev(), math.Sqrt(variance)) {
+				t.Fatalf("size %d, update %d: expected variance %v, got %v", size, i, variance, ws.Variance())
+			}
+			if ws.Min() != min || ws.Max() != max {
+				t.Fatalf("size %d, update %d: expected min/max %v/%v, got %v/%v", size, i, min, max, ws.Min(), ws.Max())
+			}
+		}
+	}
+}
+
+func TestWindowStatsEmpty(t *T) {
+	var ws WindowStats
+	for name, f := range map[string]func() float64{
+		"Mean": ws.Mean, "Variance": ws.Variance, "StdDev": ws.StdDev, "Min": ws.Min, "Max": ws.Max,
+	} {
+		if v := f(); !math.IsNaN(v) {
+			t.Errorf("%s of empty window: expected NaN, got %v", name, v)
+		}
+	}
+
+	ws.Update(3)
+	ws.Update(4)
+	if ws.Count() != 1 || ws.Mean() != 4 || ws.Variance() != 0 {
+		t.Errorf("Size 0: expected a single sample, got count %d, mean %v", ws.Count(), ws.Mean())
+	}
+}
+
+func BenchmarkWindowStats(b *B) {
+	a := WindowStats{Size: 16}
+
+	for i := 0; i < b.N; i++ {
+		a.Update(float64(i))
+		a.Average()
+	}
+}
@@ -0,0 +1,21 @@
+package chans
+
+import (
+	"time"
+)
+
+// A clock tells the time to the functions in this package which need
+// it, so that they can be tested without waiting on the real time.
+type clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// stageClock is the clock used by stages started from now on. Tests
+// replace it with a fake one.
+var stageClock clock = realClock{}
@@ -0,0 +1,156 @@
+//go:build go1.18
+// +build go1.18
+
+package chans
+
+import (
+	"sync/atomic"
+)
+
+// An Elastic is a channel with an unbounded buffer. Values sent to In
+// are held, in order, until they are received from Out, so sending
+// never blocks for long however far behind the receiver is.
+//
+// Closing In closes Out once all buffered values have been received.
+type Elastic[T any] struct {
+	in        chan T
+	out       chan T
+	length    int64
+	highWater int
+	onHigh    func(n int)
+}
+
+// NewElastic creates a new Elastic.
+//
+// If highWater is positive, onHighWater is called with the number of
+// buffered values whenever that number grows past highWater. It is
+// called again only after the buffer has dropped back to highWater or
+// below. The callback runs on the Elastic's goroutine, which is held
+// up until it returns.
+func NewElastic[T any](highWater int, onHighWater func(n int)) *Elastic[T] {
+	e := &Elastic[T]{
+		in:        make(chan T),
+		out:       make(chan T),
+		highWater: highWater,
+		onHigh:    onHighWater,
+	}
+	go e.main()
+	return e
+}
+
+// In returns the channel to send values to.
+func (e *Elastic[T]) In() chan<- T {
+	return e.in
+}
+
+// Out returns the channel to receive values from.
+func (e *Elastic[T]) Out() <-chan T {
+	return e.out
+}
+
+// Len returns the number of values sent to In but not yet received
+// from Out.
+func (e *Elastic[T]) Len() int {
+	return int(atomic.LoadInt64(&e.length))
+}
+
+func (e *Elastic[T]) main() {
+	defer close(e.out)
+
+	var (
+		buf  ring[T]
+		in   = e.in
+		high bool
+	)
+
+	for in != nil || buf.Len() > 0 {
+		var (
+			out  chan T
+			next T
+		)
+		if buf.Len() > 0 {
+			out, next = e.out, buf.Peek()
+		}
+
+		select {
+		case v, ok := <-in:
+			if !ok {
+				in = nil
+				continue
+			}
+			buf.Push(v)
+
+			n := buf.Len()
+			atomic.StoreInt64(&e.length, int64(n))
+			if !high && e.highWater > 0 && n > e.highWater {
+				high = true
+				if e.onHigh != nil {
+					e.onHigh(n)
+				}
+			}
+
+		case out <- next:
+			buf.Pop()
+
+			n := buf.Len()
+			atomic.StoreInt64(&e.length, int64(n))
+			if n <= e.highWater {
+				high = false
+			}
+		}
+	}
+}
+
+// A ring is a FIFO queue stored in a circular buffer which grows and
+// shrinks with its contents.
+type ring[T any] struct {
+	items       []T
+	head, count int
+}
+
+const minRing = 16
+
+func (r *ring[T]) Len() int {
+	return r.count
+}
+
+// Push adds v to the back of the queue.
+func (r *ring[T]) Push(v T) {
+	if r.count == len(r.items) {
+		r.resize(2 * r.count)
+	}
+	r.items[(r.head+r.count)%len(r.items)] = v
+	r.count++
+}
+
+// Peek returns the value at the front of the queue.
+func (r *ring[T]) Peek() T {
+	return r.items[r.head]
+}
+
+// Pop removes the value at the front of the queue.
+func (r *ring[T]) Pop() T {
+	var zero T
+	v := r.items[r.head]
+	r.items[r.head] = zero
+	r.head = (r.head + 1) % len(r.items)
+	r.count--
+
+	if len(r.items) > minRing && r.count <= len(r.items)/4 {
+		r.resize(len(r.items) / 2)
+	}
+	return v
+}
+
+func (r *ring[T]) resize(size int) {
+	if size < minRing {
+		size = minRing
+	}
+
+	items := make([]T, size)
+	n := copy(items, r.items[r.head:])
+	if n < r.count {
+		copy(items[n:], r.items[:r.count-n])
+	}
+	r.items, r.head = items, 0
+}
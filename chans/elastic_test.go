@@ -0,0 +1,82 @@
+//go:build go1.18
+// +build go1.18
+
+package chans
+
+import (
+	"testing"
+	"time"
+)
+
+// waitLen waits for q.Len() to reach n, as the queue's goroutine
+// may not have counted the last value sent yet.
+func waitLen(t *testing.T, q interface{ Len() int }, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for q.Len() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("Len(): expected %d, got %d", n, q.Len())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestElastic(t *testing.T) {
+	var highs []int
+	e := NewElastic[int](100, func(n int) { highs = append(highs, n) })
+
+	for round := 0; round < 2; round++ {
+		for i := 0; i < 1000; i++ {
+			e.In() <- i
+		}
+		waitLen(t, e, 1000)
+
+		for i := 0; i < 1000; i++ {
+			if got := <-e.Out(); got != i {
+				t.Fatalf("expected %d, got %d", i, got)
+			}
+		}
+		waitLen(t, e, 0)
+	}
+
+	if len(highs) != 2 || highs[0] != 101 || highs[1] != 101 {
+		t.Errorf("expected high water callbacks [101 101], got %v", highs)
+	}
+}
+
+func TestElasticClose(t *testing.T) {
+	e := NewElastic[string](0, nil)
+	for _, s := range []string{"foo", "bar", "baz"} {
+		e.In() <- s
+	}
+	close(e.In())
+
+	if got := collect(e.Out()); len(got) != 3 || got[0] != "foo" || got[2] != "baz" {
+		t.Errorf("expected [foo bar baz], got %v", got)
+	}
+}
+
+func TestRing(t *testing.T) {
+	var r ring[int]
+	next, expect := 0, 0
+
+	// Grow, shrink, and wrap around, checking the order is kept.
+	for _, step := range []struct{ push, pop int }{{40, 10}, {100, 120}, {5, 10}, {1000, 1000}} {
+		for i := 0; i < step.push; i++ {
+			r.Push(next)
+			next++
+		}
+		for i := 0; i < step.pop; i++ {
+			if got := r.Pop(); got != expect {
+				t.Fatalf("expected %d, got %d", expect, got)
+			}
+			expect++
+		}
+		if r.Len() != next-expect {
+			t.Fatalf("r.Len(): expected %d, got %d", next-expect, r.Len())
+		}
+	}
+	if len(r.items) != minRing {
+		t.Errorf("expected empty ring to shrink to %d, got %d", minRing, len(r.items))
+	}
+}
@@ -0,0 +1,225 @@
+//go:build go1.1
+// +build go1.1
+
+package chans
+
+import (
+	"errors"
+	"reflect"
+)
+
+var (
+	errInExist   = errors.New("already merged")
+	errInNone    = errors.New("not merged")
+	errMergeDone = errors.New("merge output closed")
+)
+
+// A Tagged value is sent by a Merger created with MergeTagged, to
+// identify which input channel a value came from.
+type Tagged struct {
+	Source interface{} // The input channel, as given to MergeTagged or Add
+	Value  interface{} // The value received from Source
+}
+
+// A Merger multiplexes values received from a set of input channels
+// onto a single output channel, the reverse of a Publisher.
+//
+// Once the last of its inputs is closed, the Merger closes the output
+// channel and stops. Inputs taken away with Remove don't count towards
+// this, so a Merger with no inputs left that way keeps running until
+// another input is added and closed.
+type Merger struct {
+	output reflect.Value
+	tagged bool
+	ops    chan mergeOp
+	done   chan struct{}
+}
+
+type mergeOp struct {
+	remove bool
+	ch     interface{}
+	err    chan error
+}
+
+// Merge creates a new Merger which sends all values received from the
+// ins channels to out.
+//
+// Will return an error if out is not a channel which can be sent to,
+// or if any of ins is not a channel which can be received from, with
+// an element type assignable to that of out.
+func Merge(out interface{}, ins ...interface{}) (*Merger, error) {
+	return newMerger(out, false, ins)
+}
+
+// MergeTagged is like Merge, but wraps each value sent to out in a
+// Tagged, recording the input channel it came from. The input channels
+// may have any element type.
+func MergeTagged(out chan<- Tagged, ins ...interface{}) (*Merger, error) {
+	return newMerger(out, true, ins)
+}
+
+func newMerger(out interface{}, tagged bool, ins []interface{}) (*Merger, error) {
+	vout, err := checkChannel(out, reflect.SendDir, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Merger{
+		output: vout,
+		tagged: tagged,
+		ops:    make(chan mergeOp),
+		done:   make(chan struct{}),
+	}
+
+	inputs := make([]reflect.Value, 0, len(ins))
+	for _, in := range ins {
+		vin, err := m.checkInput(in)
+		if err != nil {
+			return nil, err
+		}
+		for _, other := range inputs {
+			if other.Interface() == in {
+				return nil, errInExist
+			}
+		}
+		inputs = append(inputs, vin)
+	}
+
+	go m.main(inputs)
+
+	return m, nil
+}
+
+// Add starts merging values received from ch into the output.
+//
+// Returns an error if ch is not a suitable input channel, is already
+// being merged, or the Merger has stopped.
+func (m *Merger) Add(ch interface{}) error {
+	return m.request(mergeOp{ch: ch})
+}
+
+// Remove stops merging values received from ch into the output. A
+// value already received from ch may still be sent to the output.
+//
+// Returns an error if ch is not being merged, or the Merger has stopped.
+func (m *Merger) Remove(ch interface{}) error {
+	return m.request(mergeOp{remove: true, ch: ch})
+}
+
+// Done returns a channel which is closed once the Merger has closed
+// its output channel.
+func (m *Merger) Done() <-chan struct{} {
+	return m.done
+}
+
+func (m *Merger) request(op mergeOp) error {
+	select {
+	case <-m.done:
+		return errMergeDone
+	default:
+	}
+
+	ech := make(chan error)
+	defer close(ech)
+
+	op.err = ech
+	select {
+	case m.ops <- op:
+		return <-ech
+	case <-m.done:
+		return errMergeDone
+	}
+}
+
+func (m *Merger) checkInput(ch interface{}) (reflect.Value, error) {
+	if m.tagged {
+		return checkChannel(ch, reflect.RecvDir, nil)
+	}
+	return checkChannel(ch, reflect.RecvDir, m.output.Type().Elem())
+}
+
+func (m *Merger) main(inputs []reflect.Value) {
+	var (
+		waiting []reflect.SelectCase
+		pending reflect.Value
+	)
+	opCase := reflect.SelectCase{
+		Dir:  reflect.SelectRecv,
+		Chan: reflect.ValueOf(m.ops),
+	}
+
+	for {
+		// While holding a value, wait for the output to take it before
+		// receiving any more. Otherwise, wait on all of the inputs.
+		waiting = append(waiting[:0], opCase)
+		if pending.IsValid() {
+			waiting = append(waiting, reflect.SelectCase{
+				Dir:  reflect.SelectSend,
+				Chan: m.output,
+				Send: pending,
+			})
+		} else {
+			for _, input := range inputs {
+				waiting = append(waiting, reflect.SelectCase{
+					Dir:  reflect.SelectRecv,
+					Chan: input,
+				})
+			}
+		}
+
+		idx, val, ok := reflect.Select(waiting)
+
+		switch {
+		case idx == 0: // Add or remove an input
+			op := val.Interface().(mergeOp)
+
+			found := -1
+			for i, input := range inputs {
+				if input.Interface() == op.ch {
+					found = i
+					break
+				}
+			}
+
+			if op.remove {
+				if found < 0 {
+					op.err <- errInNone
+					break
+				}
+				inputs = append(inputs[:found], inputs[found+1:]...)
+				op.err <- nil
+				break
+			}
+
+			if found >= 0 {
+				op.err <- errInExist
+				break
+			}
+			vin, err := m.checkInput(op.ch)
+			if err == nil {
+				inputs = append(inputs, vin)
+			}
+			op.err <- err
+
+		case pending.IsValid(): // Value sent
+			pending = reflect.Value{}
+
+		case !ok: // Input closed
+			inputs = append(inputs[:idx-1], inputs[idx:]...)
+			if len(inputs) == 0 {
+				m.output.Close()
+				close(m.done)
+				return
+			}
+
+		case m.tagged: // New value, tagged with its source
+			pending = reflect.ValueOf(Tagged{
+				Source: inputs[idx-1].Interface(),
+				Value:  val.Interface(),
+			})
+
+		default: // New value
+			pending = val
+		}
+	}
+}
@@ -0,0 +1,112 @@
+//go:build go1.1
+// +build go1.1
+
+package chans
+
+import (
+	"testing"
+	"time"
+)
+
+var mergeInputTests = []struct {
+	out interface{}
+	ins []interface{}
+	err error
+}{
+	{5, nil, errNotChan},
+	{make(<-chan int), nil, errNotSend},
+	{make(chan int), []interface{}{"foo"}, errNotChan},
+	{make(chan int), []interface{}{make(chan<- int)}, errNotRecv},
+	{make(chan int), []interface{}{make(chan bool)}, errBadType},
+	{make(chan int), []interface{}{make(chan tFoo)}, errBadType},
+	{make(chan interface{}), []interface{}{make(chan tFoo), make(chan bool)}, nil},
+	{make(chan int), []interface{}{make(chan int), make(<-chan int)}, nil},
+}
+
+func TestMergeInput(t *testing.T) {
+	for _, test := range mergeInputTests {
+		m, err := Merge(test.out, test.ins...)
+		if err != test.err {
+			t.Errorf("Merge(%T, %T): expected %v, got %v", test.out, test.ins, test.err, err)
+		}
+		if err == nil {
+			for _, in := range test.ins {
+				m.Remove(in)
+			}
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	out := make(chan int)
+	a, b, c := make(chan int), make(chan int), make(chan int)
+
+	m, err := Merge(out, a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a <- 1
+	receiveAll(t, out, 1)
+	b <- 2
+	receiveAll(t, out, 2)
+
+	if err := m.Add(c); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Add(c); err != errInExist {
+		t.Errorf("m.Add(c): expected %q, got %q", errInExist, err)
+	}
+	if err := m.Add(make(chan string)); err != errBadType {
+		t.Errorf("m.Add(chan string): expected %q, got %q", errBadType, err)
+	}
+	c <- 3
+	receiveAll(t, out, 3)
+
+	if err := m.Remove(b); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Remove(b); err != errInNone {
+		t.Errorf("m.Remove(b): expected %q, got %q", errInNone, err)
+	}
+	select {
+	case b <- 4:
+		t.Error("removed input still being received from")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	close(a)
+	close(c)
+	if v, ok := <-out; ok {
+		t.Errorf("<-out: expected closed channel, got %d", v)
+	}
+	<-m.Done()
+
+	if err := m.Add(b); err != errMergeDone {
+		t.Errorf("m.Add(b): expected %q, got %q", errMergeDone, err)
+	}
+}
+
+func TestMergeTagged(t *testing.T) {
+	out := make(chan Tagged)
+	a, b := make(chan int), make(chan string)
+
+	if _, err := MergeTagged(out, a, b); err != nil {
+		t.Fatal(err)
+	}
+
+	a <- 42
+	if got := <-out; got.Source != a || got.Value != 42 {
+		t.Errorf("expected {%v 42}, got %v", a, got)
+	}
+	b <- "foo"
+	if got := <-out; got.Source != b || got.Value != "foo" {
+		t.Errorf("expected {%v foo}, got %v", b, got)
+	}
+
+	close(a)
+	close(b)
+	if _, ok := <-out; ok {
+		t.Error("<-out: expected closed channel")
+	}
+}
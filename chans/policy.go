@@ -0,0 +1,193 @@
+//go:build go1.1
+// +build go1.1
+
+package chans
+
+import (
+	"errors"
+	"reflect"
+	"time"
+)
+
+var errBadPolicy = errors.New("unknown delivery policy")
+
+// A Policy determines how a Publisher delivers messages to a
+// subscriber which is not ready to receive them.
+//
+// Each subscriber has room for a single undelivered message. The
+// Policy decides what happens when a new message arrives while
+// that room is taken.
+type Policy int
+
+const (
+	// DropOldest replaces the undelivered message with the new one.
+	// This is the default Policy.
+	DropOldest Policy = iota
+
+	// DropNewest keeps the undelivered message, and discards the
+	// new one.
+	DropNewest
+
+	// Block stops the Publisher from reading its input until the
+	// message has been delivered, so no messages are lost. A single
+	// slow Block subscriber will hold up all other subscribers.
+	Block
+
+	// Timeout behaves like Block, but gives up on the message, and
+	// resumes reading the input, once the subscriber's timeout has
+	// passed. See WithTimeout.
+	Timeout
+)
+
+var policyNames = []string{
+	DropOldest: "DropOldest",
+	DropNewest: "DropNewest",
+	Block:      "Block",
+	Timeout:    "Timeout",
+}
+
+func (p Policy) String() string {
+	if !p.valid() {
+		return "Policy(?)"
+	}
+	return policyNames[p]
+}
+
+func (p Policy) valid() bool {
+	return p >= DropOldest && p <= Timeout
+}
+
+func (p Policy) blocking() bool {
+	return p == Block || p == Timeout
+}
+
+// A SubscribeOption configures how a Publisher treats a single
+// subscriber.
+type SubscribeOption func(*output)
+
+// WithPolicy selects the delivery Policy for a subscriber.
+//
+// Using the Timeout policy without WithTimeout gives a zero timeout,
+// where a message is dropped unless the subscriber is ready to
+// receive it right away.
+func WithPolicy(policy Policy) SubscribeOption {
+	return func(o *output) {
+		o.policy = policy
+	}
+}
+
+// WithTimeout selects the Timeout delivery Policy for a subscriber,
+// where each message is given up after waiting for d.
+func WithTimeout(d time.Duration) SubscribeOption {
+	return func(o *output) {
+		o.policy = Timeout
+		o.timeout = d
+	}
+}
+
+// matching restricts a subscriber to the messages accepted by match,
+// which is also given the message's topic, if the Publisher has one.
+func matching(match func(msg reflect.Value, topic string) bool) SubscribeOption {
+	return func(o *output) {
+		o.match = match
+	}
+}
+
+// An output tracks the delivery state of a single subscriber
+// of a Publisher.
+type output struct {
+	ch       reflect.Value
+	policy   Policy
+	timeout  time.Duration
+	msg      reflect.Value
+	full     bool
+	deadline time.Time
+	dropped  uint64
+	match    func(msg reflect.Value, topic string) bool
+	replay   []reflect.Value
+
+	key        interface{}
+	hook       Hook
+	delivered  uint64
+	msgAt      time.Time
+	replayAt   time.Time
+	maxLatency time.Duration
+}
+
+func newOutput(opts []SubscribeOption) (*output, error) {
+	out := &output{policy: DropOldest}
+	for _, opt := range opts {
+		opt(out)
+	}
+	if !out.policy.valid() {
+		return nil, errBadPolicy
+	}
+	return out, nil
+}
+
+// offer queues a new message for delivery according to the
+// output's Policy, if the subscriber is interested in it.
+func (o *output) offer(msg reflect.Value, topic string) {
+	if o.match != nil && !o.match(msg, topic) {
+		return
+	}
+
+	if o.full {
+		o.dropped++
+		o.hook.Dropped(o.key)
+		if o.policy == DropNewest {
+			return
+		}
+	}
+
+	o.msg, o.full = msg, true
+	o.msgAt = time.Now()
+	if o.policy == Timeout {
+		o.deadline = o.msgAt.Add(o.timeout)
+	}
+}
+
+// pending reports whether the output has any message left to deliver.
+func (o *output) pending() bool {
+	return len(o.replay) > 0 || o.full
+}
+
+// next returns the message to deliver next. Replayed messages are
+// delivered before the live one.
+func (o *output) next() reflect.Value {
+	if len(o.replay) > 0 {
+		return o.replay[0]
+	}
+	return o.msg
+}
+
+// sent records that the message returned by next was delivered.
+func (o *output) sent() {
+	at := o.msgAt
+	if len(o.replay) > 0 {
+		at = o.replayAt
+		o.replay[0] = reflect.Value{}
+		o.replay = o.replay[1:]
+	} else {
+		o.clear()
+	}
+
+	latency := time.Since(at)
+	if latency > o.maxLatency {
+		o.maxLatency = latency
+	}
+	o.delivered++
+	o.hook.Delivered(o.key, latency)
+}
+
+// drop discards the pending message as undeliverable.
+func (o *output) drop() {
+	o.dropped++
+	o.hook.Dropped(o.key)
+	o.clear()
+}
+
+// clear empties the pending message slot.
+func (o *output) clear() {
+	o.msg, o.full = reflect.Value{}, false
+}
@@ -0,0 +1,106 @@
+//go:build go1.1
+// +build go1.1
+
+package chans
+
+import (
+	"testing"
+	"time"
+)
+
+// publishAll subscribes out to a new Publisher with the given options,
+// sends msgs through it without receiving any, and returns the
+// input channel.
+func publishAll(t *testing.T, out chan int, msgs []int, opts ...SubscribeOption) (chan int, *Publisher) {
+	in := make(chan int)
+	p := newPublisher(in, t)
+
+	if err := p.Subscribe(out, opts...); err != nil {
+		t.Fatalf("p.Subscribe(%v): %v", opts, err)
+	}
+	for _, msg := range msgs {
+		in <- msg
+	}
+	return in, p
+}
+
+func checkDropped(t *testing.T, p *Publisher, ch interface{}, expect uint64) {
+	n, err := p.Dropped(ch)
+	if err != nil {
+		t.Fatalf("p.Dropped(): %v", err)
+	}
+	if n != expect {
+		t.Errorf("p.Dropped(): expected %d, got %d", expect, n)
+	}
+}
+
+var policyTests = []struct {
+	policy   Policy
+	msgs     []int
+	received int
+	dropped  uint64
+}{
+	{DropOldest, []int{1}, 1, 0},
+	{DropOldest, []int{1, 2, 3}, 3, 2},
+	{DropNewest, []int{1}, 1, 0},
+	{DropNewest, []int{1, 2, 3}, 1, 2},
+}
+
+func TestPolicyDrop(t *testing.T) {
+	for _, test := range policyTests {
+		out := make(chan int)
+		in, p := publishAll(t, out, test.msgs, WithPolicy(test.policy))
+
+		if got := <-out; got != test.received {
+			t.Errorf("%v: expected %d, got %d", test.policy, test.received, got)
+		}
+		checkDropped(t, p, out, test.dropped)
+		close(in)
+	}
+}
+
+func TestPolicyBlock(t *testing.T) {
+	out := make(chan int)
+	in, p := publishAll(t, out, []int{1}, WithPolicy(Block))
+	defer close(in)
+
+	select {
+	case in <- 2:
+		t.Fatal("Publisher accepted input while a Block subscriber was waiting")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	for _, expect := range []int{1, 2, 3} {
+		if expect > 1 {
+			in <- expect
+		}
+		if got := <-out; got != expect {
+			t.Errorf("expected %d, got %d", expect, got)
+		}
+	}
+	checkDropped(t, p, out, 0)
+}
+
+func TestPolicyTimeout(t *testing.T) {
+	out := make(chan int)
+	in, p := publishAll(t, out, []int{1, 2, 3}, WithTimeout(5*time.Millisecond))
+	defer close(in)
+
+	if got := <-out; got != 3 {
+		t.Errorf("expected 3, got %d", got)
+	}
+	checkDropped(t, p, out, 2)
+}
+
+func TestPolicyErrors(t *testing.T) {
+	in, out := make(chan int), make(chan int)
+	p := newPublisher(in, t)
+	defer close(in)
+
+	if err := p.Subscribe(out, WithPolicy(Policy(42))); err != errBadPolicy {
+		t.Errorf("p.Subscribe(Policy(42)): expected %q, got %q", errBadPolicy, err)
+	}
+	if _, err := p.Dropped(out); err != errSubNone {
+		t.Errorf("p.Dropped(): expected %q, got %q", errSubNone, err)
+	}
+}
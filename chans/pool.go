@@ -0,0 +1,160 @@
+//go:build go1.18
+// +build go1.18
+
+package chans
+
+import (
+	"context"
+	"sync"
+)
+
+// A Pool runs a function over the values received from a channel,
+// using a bounded number of goroutines.
+type Pool[T, U any] struct {
+	// Workers is the number of values processed at once. Values
+	// less than 1 are treated as 1.
+	Workers int
+
+	// Ordered makes the results be sent in the same order as their
+	// inputs were received. Otherwise each result is sent as soon as
+	// it is ready.
+	Ordered bool
+
+	// Func computes the result for a single value. The context given
+	// to it is cancelled once any call has failed.
+	Func func(ctx context.Context, v T) (U, error)
+}
+
+type poolJob[T any] struct {
+	seq int
+	v   T
+}
+
+type poolResult[U any] struct {
+	seq int
+	v   U
+	err error
+}
+
+// ParallelMap runs f over the values received from in, with at most
+// workers calls at once, and sends the results in input order. It is
+// shorthand for running an ordered Pool.
+func ParallelMap[T, U any](ctx context.Context, in <-chan T, workers int, f func(context.Context, T) (U, error)) (<-chan U, func() error) {
+	return Pool[T, U]{Workers: workers, Ordered: true, Func: f}.Run(ctx, in)
+}
+
+// Run starts the Pool processing the values received from in, and
+// returns a channel carrying the results, along with a function which
+// waits for the Pool to finish.
+//
+// The results channel is closed once in has been closed and all of its
+// values are processed, or as soon as a call to Func fails or ctx is
+// done. The wait function returns the first error from Func, or the
+// error from ctx if it was done first, or nil if all values succeeded.
+//
+// At most Workers values are held at any time, so with Ordered set, a
+// slow value holds up the values received after it.
+func (p Pool[T, U]) Run(ctx context.Context, in <-chan T) (<-chan U, func() error) {
+	workers := p.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+
+	var (
+		out     = make(chan U)
+		jobs    = make(chan poolJob[T])
+		results = make(chan poolResult[U])
+		tokens  = make(chan struct{}, workers)
+		done    = make(chan struct{})
+		err     error
+	)
+
+	// Hand out values, as long as there is room for them.
+	go func() {
+		defer close(jobs)
+		for seq := 0; ; seq++ {
+			select {
+			case tokens <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+
+			select {
+			case v, ok := <-in:
+				if !ok || !send(ctx, jobs, poolJob[T]{seq, v}) {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				v, err := p.Func(ctx, job.v)
+				if !send(ctx, results, poolResult[U]{job.seq, v, err}) {
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// Collect results, and send them on in the right order.
+	go func() {
+		defer close(done)
+		defer close(out)
+		defer cancel()
+
+		fail := func(e error) {
+			if err == nil {
+				err = e
+				cancel()
+			}
+		}
+		emit := func(v U) {
+			if send(ctx, out, v) {
+				<-tokens
+			}
+		}
+
+		held := map[int]U{}
+		next := 0
+		for result := range results {
+			switch {
+			case result.err != nil:
+				fail(result.err)
+			case ctx.Err() != nil:
+			case !p.Ordered:
+				emit(result.v)
+			default:
+				held[result.seq] = result.v
+				for v, ok := held[next]; ok; v, ok = held[next] {
+					delete(held, next)
+					next++
+					emit(v)
+				}
+			}
+		}
+
+		if parent.Err() != nil {
+			fail(parent.Err())
+		}
+	}()
+
+	return out, func() error {
+		<-done
+		return err
+	}
+}
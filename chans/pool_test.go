@@ -0,0 +1,120 @@
+//go:build go1.18
+// +build go1.18
+
+package chans
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"reflect"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func count(n int) <-chan int {
+	values := make([]int, n)
+	for i := range values {
+		values[i] = i
+	}
+	return feed(values...)
+}
+
+// jitter sleeps for a short random time, so results finish out of order.
+func jitter() {
+	time.Sleep(time.Duration(rand.Intn(500)) * time.Microsecond)
+}
+
+func TestParallelMapOrdered(t *testing.T) {
+	var running, peak int32
+	square := func(_ context.Context, x int) (int, error) {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		defer atomic.AddInt32(&running, -1)
+
+		jitter()
+		return x * x, nil
+	}
+
+	out, wait := ParallelMap(context.Background(), count(100), 4, square)
+	got := collect(out)
+	if err := wait(); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, v := range got {
+		if v != i*i {
+			t.Fatalf("result %d: expected %d, got %d", i, i*i, v)
+		}
+	}
+	if len(got) != 100 {
+		t.Errorf("expected 100 results, got %d", len(got))
+	}
+	if peak > 4 {
+		t.Errorf("expected at most 4 workers running, saw %d", peak)
+	}
+}
+
+func TestPoolUnordered(t *testing.T) {
+	pool := Pool[int, int]{
+		Workers: 8,
+		Func: func(_ context.Context, x int) (int, error) {
+			jitter()
+			return x, nil
+		},
+	}
+
+	out, wait := pool.Run(context.Background(), count(50))
+	got := collect(out)
+	if err := wait(); err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Ints(got)
+	if expect := collect(count(50)); !reflect.DeepEqual(got, expect) {
+		t.Errorf("expected %v, got %v", expect, got)
+	}
+}
+
+func TestPoolError(t *testing.T) {
+	errBoom := errors.New("boom")
+	fail := func(ctx context.Context, x int) (int, error) {
+		if x == 10 {
+			return 0, errBoom
+		}
+		return x, nil
+	}
+
+	out, wait := ParallelMap(context.Background(), count(1000), 4, fail)
+	got := collect(out)
+	if err := wait(); err != errBoom {
+		t.Errorf("wait(): expected %q, got %q", errBoom, err)
+	}
+	if len(got) > 10 {
+		t.Errorf("expected results to stop at the failure, got %d", len(got))
+	}
+}
+
+func TestPoolCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	block := func(ctx context.Context, x int) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}
+
+	out, wait := ParallelMap(ctx, count(10), 2, block)
+	cancel()
+	if got := collect(out); len(got) != 0 {
+		t.Errorf("expected no results, got %v", got)
+	}
+	if err := wait(); err != context.Canceled {
+		t.Errorf("wait(): expected %q, got %q", context.Canceled, err)
+	}
+}
@@ -0,0 +1,85 @@
+//go:build go1.18
+// +build go1.18
+
+package chans
+
+import (
+	"container/heap"
+	"sync/atomic"
+
+	"github.com/cookieo9/go-misc/slice"
+)
+
+// A PriorityQueue is a channel-like queue which holds the values sent
+// to In, and always offers the highest priority one to receivers of
+// Out. Like an Elastic, its buffer is unbounded.
+//
+// Closing In closes Out once all held values have been received.
+type PriorityQueue[T any] struct {
+	in     chan T
+	out    chan T
+	length int64
+	before func(a, b T) bool
+}
+
+// NewPriorityQueue creates a new PriorityQueue, where the comparator
+// before returns true if a has higher priority than b, and should be
+// received first. As in the slice package, the comparator must be
+// consistent, but may return true for equal values.
+func NewPriorityQueue[T any](before func(a, b T) bool) *PriorityQueue[T] {
+	pq := &PriorityQueue[T]{
+		in:     make(chan T),
+		out:    make(chan T),
+		before: before,
+	}
+	go pq.main()
+	return pq
+}
+
+// In returns the channel to send values to.
+func (pq *PriorityQueue[T]) In() chan<- T {
+	return pq.in
+}
+
+// Out returns the channel to receive values from, highest
+// priority first.
+func (pq *PriorityQueue[T]) Out() <-chan T {
+	return pq.out
+}
+
+// Len returns the number of values sent to In but not yet received
+// from Out.
+func (pq *PriorityQueue[T]) Len() int {
+	return int(atomic.LoadInt64(&pq.length))
+}
+
+func (pq *PriorityQueue[T]) main() {
+	defer close(pq.out)
+
+	var items []T
+	h := slice.WrapTyped(&items, pq.before)
+	in := pq.in
+
+	for in != nil || len(items) > 0 {
+		var (
+			out  chan T
+			next T
+		)
+		if len(items) > 0 {
+			out, next = pq.out, items[0]
+		}
+
+		select {
+		case v, ok := <-in:
+			if !ok {
+				in = nil
+				continue
+			}
+			heap.Push(h, v)
+
+		case out <- next:
+			heap.Pop(h)
+		}
+		atomic.StoreInt64(&pq.length, int64(len(items)))
+	}
+}
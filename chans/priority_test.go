@@ -0,0 +1,58 @@
+//go:build go1.18
+// +build go1.18
+
+package chans
+
+import (
+	"reflect"
+	"testing"
+)
+
+type job struct {
+	Name     string
+	Priority int
+}
+
+func TestPriorityQueue(t *testing.T) {
+	pq := NewPriorityQueue(func(a, b int) bool { return a > b })
+
+	for _, v := range []int{3, 1, 4, 1, 5, 9, 2, 6} {
+		pq.In() <- v
+	}
+	for _, expect := range []int{9, 6, 5} {
+		if got := <-pq.Out(); got != expect {
+			t.Errorf("expected %d, got %d", expect, got)
+		}
+	}
+
+	// Newly arrived values go ahead of lower priority ones.
+	pq.In() <- 7
+	pq.In() <- 0
+	close(pq.In())
+
+	if got, expect := collect(pq.Out()), []int{7, 4, 3, 2, 1, 1, 0}; !reflect.DeepEqual(got, expect) {
+		t.Errorf("expected %v, got %v", expect, got)
+	}
+	if n := pq.Len(); n != 0 {
+		t.Errorf("pq.Len(): expected 0, got %d", n)
+	}
+}
+
+func TestPriorityQueueStruct(t *testing.T) {
+	pq := NewPriorityQueue(func(a, b job) bool { return a.Priority < b.Priority })
+
+	jobs := []job{{"low", 3}, {"urgent", 0}, {"normal", 2}, {"high", 1}}
+	for _, j := range jobs {
+		pq.In() <- j
+	}
+	waitLen(t, pq, len(jobs))
+	close(pq.In())
+
+	var names []string
+	for j := range pq.Out() {
+		names = append(names, j.Name)
+	}
+	if expect := []string{"urgent", "high", "normal", "low"}; !reflect.DeepEqual(names, expect) {
+		t.Errorf("expected %v, got %v", expect, names)
+	}
+}
@@ -5,7 +5,7 @@ package chans
 import (
 	"errors"
 	"reflect"
-	"sync"
+	"time"
 )
 
 var (
@@ -18,19 +18,59 @@ var (
 	errSubNone  = errors.New("not subscribed")
 )
 
+// ErrClosed is reported by Publisher.Err once the Publisher has
+// stopped because its input channel was closed.
+var ErrClosed = errPubDead
+
 // A Publisher maintains a publish/subscribe relationship between
 // an input channel, and a set of output channels.
 type Publisher struct {
-	input reflect.Value
-	subs  chan sub
-	dead  bool
-	lock  sync.Mutex
+	input     reflect.Value
+	subs      chan sub
+	done      chan struct{}
+	err       error
+	closeSubs bool
+	cancel    <-chan struct{}
+	reason    func() error
+	topic     func(interface{}) string
+	history   history
+	hook      Hook
+	received  uint64
 }
 
+// A PublisherOption configures a Publisher when it is created.
+type PublisherOption func(*Publisher)
+
+// CloseSubscribers makes a Publisher close all subscribed channels
+// once its input channel is closed, or the Publisher is cancelled,
+// so receivers ranging over them finish.
+//
+// Each channel is closed after its pending message has been handled
+// according to its delivery Policy. Until then, the Publisher keeps
+// running, and Unsubscribe can be used to release a subscriber which
+// never receives.
+func CloseSubscribers() PublisherOption {
+	return func(p *Publisher) {
+		p.closeSubs = true
+	}
+}
+
+type subOp int
+
+const (
+	opSubscribe subOp = iota
+	opUnsubscribe
+	opDropped
+	opStats
+)
+
 type sub struct {
-	unsub bool
-	ch    interface{}
-	err   chan error
+	op      subOp
+	ch      interface{}
+	out     *output
+	dropped *uint64
+	stats   *Stats
+	err     chan error
 }
 
 // NewPublisher creates a new Publisher that reads messages from
@@ -38,14 +78,16 @@ type sub struct {
 //
 // Will return an error if the ch argument is not a channel or
 // cannot be received from.
-func NewPublisher(ch interface{}) (*Publisher, error) {
+func NewPublisher(ch interface{}, opts ...PublisherOption) (*Publisher, error) {
 	pub := new(Publisher)
 
 	if err := pub.init(ch); err != nil {
 		return nil, err
 	}
+	for _, opt := range opts {
+		opt(pub)
+	}
 
-	pub.lock.Lock()
 	go pub.main()
 
 	return pub, nil
@@ -59,61 +101,130 @@ func (p *Publisher) init(ch interface{}) error {
 
 	p.input = vch
 	p.subs = make(chan sub)
+	p.done = make(chan struct{})
+	p.hook = nopHook{}
 
 	return nil
 }
 
+// Done returns a channel which is closed once the Publisher has
+// stopped, and will no longer send to any subscriber.
+func (p *Publisher) Done() <-chan struct{} {
+	return p.done
+}
+
+// Err returns nil while the Publisher is running. Once Done is
+// closed, it returns the reason the Publisher stopped.
+func (p *Publisher) Err() error {
+	select {
+	case <-p.done:
+		return p.err
+	default:
+		return nil
+	}
+}
+
 // Unsubscribe stops the given channel from recieving messages
-// sent through the Publisher.
+// sent through the Publisher. A message still waiting to be
+// delivered to the channel is discarded.
 //
 // Returns an error if the channel was never subscribed to the
 // Publisher.
 func (p *Publisher) Unsubscribe(ch interface{}) error {
-	p.lock.Lock()
-	defer p.lock.Unlock()
-
-	if p.dead {
-		return errPubDead
-	}
-
-	ech := make(chan error)
-	defer close(ech)
-
-	p.subs <- sub{
-		unsub: true,
-		ch:    ch,
-		err:   ech,
-	}
-	return <-ech
+	return p.request(sub{
+		op: opUnsubscribe,
+		ch: ch,
+	})
 }
 
 // Subscribe adds the given channel to the Publisher's list of subscribers
 // and will begin to receive messages sent to the Publisher's input channel.
 //
+// By default, a message which has not been received by the time the next
+// one arrives is replaced by it (see DropOldest). Options can be given to
+// choose a different delivery Policy for this subscriber.
+//
 // Returns an error if ch:
 //  - is not a channel
 //  - cannot be sent to
 //  - has a different element type than the input channel
 //  - is already subscribed to the Publisher
-func (p *Publisher) Subscribe(ch interface{}) error {
-	p.lock.Lock()
-	defer p.lock.Unlock()
+//
+// or if the options select an unknown Policy.
+func (p *Publisher) Subscribe(ch interface{}, opts ...SubscribeOption) error {
+	out, err := newOutput(opts)
+	if err != nil {
+		return err
+	}
 
-	if p.dead {
-		return errPubDead
+	return p.request(sub{
+		op:  opSubscribe,
+		ch:  ch,
+		out: out,
+	})
+}
+
+// Dropped returns the number of messages the Publisher has discarded
+// instead of delivering them to the given channel, under the channel's
+// delivery Policy.
+//
+// Returns an error if the channel is not subscribed to the Publisher.
+func (p *Publisher) Dropped(ch interface{}) (uint64, error) {
+	var n uint64
+	err := p.request(sub{
+		op:      opDropped,
+		ch:      ch,
+		dropped: &n,
+	})
+	return n, err
+}
+
+func (p *Publisher) request(s sub) error {
+	return p.requestCancel(s, nil, nil)
+}
+
+// requestCancel passes s to the Publisher's main goroutine, and returns
+// its reply. If cancel is closed before the request could be handed
+// over, it gives up and returns the error from reason instead. If the
+// Publisher has stopped, it returns the reason it stopped, as from Err.
+func (p *Publisher) requestCancel(s sub, cancel <-chan struct{}, reason func() error) error {
+	select {
+	case <-p.done:
+		return p.err
+	default:
 	}
 
 	ech := make(chan error)
 	defer close(ech)
 
-	p.subs <- sub{
-		ch:  ch,
-		err: ech,
+	s.err = ech
+	select {
+	case p.subs <- s:
+		return <-ech
+	case <-p.done:
+		return p.err
+	case <-cancel:
+		return reason()
 	}
-	return <-ech
+}
+
+// stop marks the Publisher as stopped, and reports err as the reason.
+func (p *Publisher) stop(err error) {
+	p.err = err
+	close(p.done)
 }
 
 func (p *Publisher) checkChannel(ch interface{}, input bool) (reflect.Value, error) {
+	if input {
+		return checkChannel(ch, reflect.RecvDir, nil)
+	}
+	return checkChannel(ch, reflect.SendDir, p.input.Type().Elem())
+}
+
+// checkChannel checks that ch is a channel which can be used in
+// direction dir. When elem is not nil, it also checks that values
+// can be passed between ch and a channel of element type elem.
+func checkChannel(ch interface{}, dir reflect.ChanDir, elem reflect.Type) (reflect.Value, error) {
 	vch := reflect.ValueOf(ch)
 	vnil := reflect.ValueOf(nil)
 
@@ -121,19 +232,21 @@ func (p *Publisher) checkChannel(ch interface{}, input bool) (reflect.Value, err
 		return vnil, errNotChan
 	}
 
-	if input {
-		if vch.Type().ChanDir()&reflect.RecvDir == 0 {
+	if vch.Type().ChanDir()&dir == 0 {
+		if dir == reflect.RecvDir {
 			return vnil, errNotRecv
 		}
-		return vch, nil
-	}
-
-	if vch.Type().ChanDir()&reflect.SendDir == 0 {
 		return vnil, errNotSend
 	}
 
-	if !p.input.Type().Elem().AssignableTo(vch.Type().Elem()) {
-		return vnil, errBadType
+	if elem != nil {
+		from, to := vch.Type().Elem(), elem
+		if dir == reflect.SendDir {
+			from, to = to, from
+		}
+		if !from.AssignableTo(to) {
+			return vnil, errBadType
+		}
 	}
 
 	return vch, nil
@@ -141,91 +254,232 @@ func (p *Publisher) checkChannel(ch interface{}, input bool) (reflect.Value, err
 
 func (p *Publisher) main() {
 	var (
-		subscribers   []reflect.Value
-		subscriberSet = map[interface{}]bool{}
+		subscribers   []*output
+		subscriberSet = map[interface{}]*output{}
 		waiting       []reflect.SelectCase
+		targets       []*output
 	)
-	p.lock.Unlock()
 
-	inputCase := reflect.SelectCase{
-		Dir:  reflect.SelectRecv,
-		Chan: p.input,
-	}
-	subCase := reflect.SelectCase{
-		Dir:  reflect.SelectRecv,
-		Chan: reflect.ValueOf(p.subs),
-	}
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+	timerChan := reflect.ValueOf(timer.C)
+	defer timer.Stop()
+
+	draining := false
 
-	waiting = append(waiting, inputCase)
-	waiting = append(waiting, subCase)
+	// release lets go of all subscribers, closing their channels
+	// if requested.
+	release := func(close bool) {
+		for _, subscriber := range subscribers {
+			if close {
+				subscriber.ch.Close()
+			}
+			p.hook.Unsubscribed(subscriber.key)
+		}
+	}
 
 	for {
+		if draining {
+			// Close the subscribers which have nothing left to deliver
+			remaining := subscribers[:0]
+			for _, subscriber := range subscribers {
+				if subscriber.pending() {
+					remaining = append(remaining, subscriber)
+					continue
+				}
+				delete(subscriberSet, subscriber.key)
+				subscriber.ch.Close()
+				p.hook.Unsubscribed(subscriber.key)
+			}
+			for idx := len(remaining); idx < len(subscribers); idx++ {
+				subscribers[idx] = nil
+			}
+			subscribers = remaining
+
+			if len(subscribers) == 0 {
+				p.stop(ErrClosed)
+				return
+			}
+		}
+
+		// Build the cases for this round: the input (unless a blocking
+		// subscriber still has a message outstanding), new subscribers,
+		// the earliest delivery deadline, cancellation, and all pending
+		// deliveries.
+		waiting = append(waiting[:0],
+			reflect.SelectCase{Dir: reflect.SelectRecv, Chan: p.input},
+			reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(p.subs)},
+			reflect.SelectCase{Dir: reflect.SelectRecv},
+			reflect.SelectCase{Dir: reflect.SelectRecv},
+		)
+		if p.cancel != nil {
+			waiting[3].Chan = reflect.ValueOf(p.cancel)
+		}
+		targets = targets[:0]
+		if draining {
+			waiting[0].Chan = reflect.Value{}
+		}
+
+		var deadline time.Time
+		for _, subscriber := range subscribers {
+			if !subscriber.pending() {
+				continue
+			}
+			if subscriber.full && subscriber.policy.blocking() {
+				waiting[0].Chan = reflect.Value{}
+			}
+			if subscriber.full && subscriber.policy == Timeout && (deadline.IsZero() || subscriber.deadline.Before(deadline)) {
+				deadline = subscriber.deadline
+			}
+			waiting = append(waiting, reflect.SelectCase{
+				Dir:  reflect.SelectSend,
+				Chan: subscriber.ch,
+				Send: subscriber.next(),
+			})
+			targets = append(targets, subscriber)
+		}
+
+		if !deadline.IsZero() {
+			timer.Reset(deadline.Sub(time.Now()))
+			waiting[2].Chan = timerChan
+		}
+
 		idx, val, ok := reflect.Select(waiting)
 
-		switch idx {
-		case 0: // New message
-			if !ok { // input channel closed
-				p.lock.Lock()
-				defer p.lock.Unlock()
+		if !deadline.IsZero() && !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
 
-				close(p.subs)
-				p.dead = true
+		closed := idx == 0 && !ok
+		if idx == 1 && waiting[0].Chan.IsValid() {
+			// Take any input that is already waiting before handling a
+			// request, so a request made after the input channel was
+			// closed reliably sees the Publisher as dead.
+			if msg, more := p.input.TryRecv(); msg.IsValid() {
+				if more {
+					p.offerAll(subscribers, msg)
+				}
+				closed = !more
+			}
+		}
 
+		if closed { // input channel closed
+			if !p.closeSubs {
+				if idx == 1 {
+					val.Interface().(sub).err <- errPubDead
+				}
+				release(false)
+				p.stop(ErrClosed)
 				return
 			}
-
-			// Send message to all current subscribers
-			waiting = waiting[:2]
-			for _, subscriber := range subscribers {
-				waiting = append(waiting, reflect.SelectCase{
-					Dir:  reflect.SelectSend,
-					Chan: subscriber,
-					Send: val,
-				})
+			draining = true
+			if idx == 0 {
+				continue
 			}
+		}
+
+		switch idx {
+		case 0: // New message
+			// Queue message for all current subscribers
+			p.offerAll(subscribers, val)
 
 		case 1: // New subscriber
 			subscription := val.Interface().(sub)
 			ch := subscription.ch
 
-			if subscription.unsub { // Removing a subscription
-				if !subscriberSet[ch] { // No match
+			switch subscription.op {
+			case opUnsubscribe: // Removing a subscription
+				subscriber, ok := subscriberSet[ch]
+				if !ok { // No match
 					subscription.err <- errSubNone
-					break
+					continue
 				}
 
 				// Find and remove
-				for idx, subscriber := range subscribers {
-					if subscriber.Interface() == ch {
-						delete(subscriberSet, ch)
-
+				delete(subscriberSet, ch)
+				for idx := range subscribers {
+					if subscribers[idx] == subscriber {
 						n := len(subscribers)
 						subscribers[idx] = subscribers[n-1]
+						subscribers[n-1] = nil
 						subscribers = subscribers[:n-1]
 						break
 					}
 				}
-			} else {
+				p.hook.Unsubscribed(ch)
+
+			case opDropped:
+				subscriber, ok := subscriberSet[ch]
+				if !ok {
+					subscription.err <- errSubNone
+					continue
+				}
+				*subscription.dropped = subscriber.dropped
+
+			case opStats:
+				*subscription.stats = p.snapshot(subscribers)
+
+			default:
+				if draining { // No new subscribers after input closed
+					subscription.err <- errPubDead
+					continue
+				}
+
 				vch, err := p.checkChannel(ch, false)
 				if err != nil { // bad channel
 					subscription.err <- err
-					break
+					continue
 				}
 
-				if subscriberSet[ch] { // Already exists
+				if subscriberSet[ch] != nil { // Already exists
 					subscription.err <- errSubExist
-					break
-				} else { // Add subscription
-					subscriberSet[ch] = true
-					subscribers = append(subscribers, vch)
+					continue
 				}
+
+				// Add subscription, starting with any replayed messages
+				subscriber := subscription.out
+				subscriber.ch = vch
+				subscriber.key = ch
+				subscriber.hook = p.hook
+				p.history.replay(subscriber)
+				subscriberSet[ch] = subscriber
+				subscribers = append(subscribers, subscriber)
+				p.hook.Subscribed(ch)
 			}
 			subscription.err <- nil
 
+		case 2: // Delivery deadline passed
+			now := time.Now()
+			for _, subscriber := range subscribers {
+				if subscriber.full && subscriber.policy == Timeout && !now.Before(subscriber.deadline) {
+					subscriber.drop()
+				}
+			}
+
+		case 3: // Cancelled
+			release(p.closeSubs)
+			p.stop(p.reason())
+			return
+
 		default: // Value sent
-			n := len(waiting)
-			waiting[idx] = waiting[n-1]
-			waiting = waiting[:n-1]
+			targets[idx-4].sent()
 		}
 	}
 }
+
+// offerAll queues msg for delivery to each of the subscribers.
+func (p *Publisher) offerAll(subscribers []*output, msg reflect.Value) {
+	var topic string
+	if p.topic != nil {
+		topic = p.topic(msg.Interface())
+	}
+	p.history.record(msg, topic)
+	p.received++
+
+	for _, subscriber := range subscribers {
+		subscriber.offer(msg, topic)
+	}
+}
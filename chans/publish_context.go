@@ -0,0 +1,58 @@
+//go:build go1.7
+// +build go1.7
+
+package chans
+
+import (
+	"context"
+)
+
+// NewPublisherContext creates a new Publisher that reads messages from
+// the given channel until either the channel is closed, or ctx is done.
+//
+// Once ctx is done, the Publisher stops without delivering any pending
+// messages, releases its subscribers (closing them if CloseSubscribers
+// was given), and Err reports ctx.Err().
+//
+// Will return an error if the ch argument is not a channel or
+// cannot be received from.
+func NewPublisherContext(ctx context.Context, ch interface{}, opts ...PublisherOption) (*Publisher, error) {
+	pub := new(Publisher)
+
+	if err := pub.init(ch); err != nil {
+		return nil, err
+	}
+	for _, opt := range opts {
+		opt(pub)
+	}
+	pub.cancel = ctx.Done()
+	pub.reason = ctx.Err
+
+	go pub.main()
+
+	return pub, nil
+}
+
+// SubscribeContext is like Subscribe, but gives up waiting for the
+// Publisher and returns ctx.Err() if ctx is done first.
+func (p *Publisher) SubscribeContext(ctx context.Context, ch interface{}, opts ...SubscribeOption) error {
+	out, err := newOutput(opts)
+	if err != nil {
+		return err
+	}
+
+	return p.requestCancel(sub{
+		op:  opSubscribe,
+		ch:  ch,
+		out: out,
+	}, ctx.Done(), ctx.Err)
+}
+
+// UnsubscribeContext is like Unsubscribe, but gives up waiting for the
+// Publisher and returns ctx.Err() if ctx is done first.
+func (p *Publisher) UnsubscribeContext(ctx context.Context, ch interface{}) error {
+	return p.requestCancel(sub{
+		op: opUnsubscribe,
+		ch: ch,
+	}, ctx.Done(), ctx.Err)
+}
@@ -0,0 +1,72 @@
+//go:build go1.7
+// +build go1.7
+
+package chans
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPublisherContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	in, out := make(chan int), make(chan int)
+
+	p, err := NewPublisherContext(ctx, in, CloseSubscribers())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.SubscribeContext(ctx, out); err != nil {
+		t.Fatal(err)
+	}
+
+	in <- 1
+	cancel()
+
+	select {
+	case <-p.Done():
+	case <-time.After(time.Second):
+		t.Fatal("p.Done() not closed after cancel")
+	}
+	if err := p.Err(); err != context.Canceled {
+		t.Errorf("p.Err(): expected %q, got %q", context.Canceled, err)
+	}
+
+	// The pending message is discarded, and the subscriber closed.
+	if v, ok := <-out; ok {
+		t.Errorf("<-out: expected closed channel, got %d", v)
+	}
+
+	if err := p.Subscribe(make(chan int)); err != context.Canceled {
+		t.Errorf("p.Subscribe(): expected %q, got %q", context.Canceled, err)
+	}
+	if err := p.UnsubscribeContext(context.Background(), out); err != context.Canceled {
+		t.Errorf("p.UnsubscribeContext(): expected %q, got %q", context.Canceled, err)
+	}
+	if err := p.SubscribeContext(context.Background(), make(chan int)); err != context.Canceled {
+		t.Errorf("p.SubscribeContext(): expected %q, got %q", context.Canceled, err)
+	}
+}
+
+func TestPublisherSubscribeContext(t *testing.T) {
+	// A Publisher whose main goroutine never runs, so requests
+	// can't be handed over.
+	p := new(Publisher)
+	if err := p.init(make(chan int)); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := p.SubscribeContext(ctx, make(chan int)); err != context.DeadlineExceeded {
+		t.Errorf("p.SubscribeContext(): expected %q, got %q", context.DeadlineExceeded, err)
+	}
+	if err := p.UnsubscribeContext(ctx, make(chan int)); err != context.DeadlineExceeded {
+		t.Errorf("p.UnsubscribeContext(): expected %q, got %q", context.DeadlineExceeded, err)
+	}
+	if err := p.SubscribeContext(ctx, make(chan int), WithPolicy(Policy(-1))); err != errBadPolicy {
+		t.Errorf("p.SubscribeContext(Policy(-1)): expected %q, got %q", errBadPolicy, err)
+	}
+}
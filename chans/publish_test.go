@@ -5,6 +5,7 @@ package chans
 import (
 	"reflect"
 	"testing"
+	"time"
 )
 
 func newPublisher(x interface{}, t *testing.T) *Publisher {
@@ -106,3 +107,86 @@ func TestPublisherSingleSubscriber(t *testing.T) {
 		}
 	}
 }
+
+func TestPublisherDone(t *testing.T) {
+	a := make(chan int)
+	p := newPublisher(a, t)
+
+	if err := p.Err(); err != nil {
+		t.Fatalf("p.Err(): expected nil while running, got %q", err)
+	}
+	close(a)
+
+	select {
+	case <-p.Done():
+	case <-time.After(time.Second):
+		t.Fatal("p.Done() not closed after input closed")
+	}
+	if err := p.Err(); err != ErrClosed {
+		t.Fatalf("p.Err(): expected %q, got %q", ErrClosed, err)
+	}
+}
+
+func TestPublisherCloseSubscribers(t *testing.T) {
+	in := make(chan int)
+	p, err := NewPublisher(in, CloseSubscribers())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outs := []chan int{make(chan int), make(chan int)}
+	if err := p.Subscribe(outs[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Subscribe(outs[1], WithPolicy(Block)); err != nil {
+		t.Fatal(err)
+	}
+
+	in <- 42
+	close(in)
+
+	// The Block subscriber holds back the input, so it must be
+	// received from first for the publisher to see it closed.
+	for i := len(outs) - 1; i >= 0; i-- {
+		var got []int
+		out := outs[i]
+		for v := range out {
+			got = append(got, v)
+		}
+		if len(got) != 1 || got[0] != 42 {
+			t.Errorf("outs[%d]: expected [42], got %v", i, got)
+		}
+	}
+
+	<-p.Done()
+	if err := p.Err(); err != ErrClosed {
+		t.Errorf("p.Err(): expected %q, got %q", ErrClosed, err)
+	}
+	if err := p.Subscribe(make(chan int)); err != errPubDead {
+		t.Errorf("p.Subscribe(): expected %q, got %q", errPubDead, err)
+	}
+}
+
+func TestPublisherSubscribeWhileClosing(t *testing.T) {
+	a := make(chan int)
+	p := newPublisher(a, t)
+
+	started, done := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; p.Subscribe(make(chan int)) == nil; i++ {
+			if i == 0 {
+				close(started)
+			}
+		}
+	}()
+	<-started
+	close(a)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("p.Subscribe() blocked after input closed")
+	}
+	<-p.Done()
+}
@@ -0,0 +1,229 @@
+//go:build go1.18
+// +build go1.18
+
+package chans
+
+import (
+	"sync"
+)
+
+// A TypedPublisher is the type-safe counterpart of Publisher. It maintains
+// a publish/subscribe relationship between an input channel of element
+// type T, and a set of output channels of the same element type.
+//
+// Unlike Publisher, mismatched channel types are caught by the compiler,
+// and messages are delivered without going through reflection.
+type TypedPublisher[T any] struct {
+	input <-chan T
+	subs  chan typedSub[T]
+	done  chan struct{}
+}
+
+type typedSub[T any] struct {
+	unsub bool
+	ch    chan<- T
+	err   chan error
+}
+
+// A typedOutput delivers messages to a single subscriber of a
+// TypedPublisher. It holds at most one undelivered message, which is
+// replaced if a newer message arrives before it could be sent.
+type typedOutput[T any] struct {
+	ch      chan<- T
+	notify  chan struct{}
+	quit    chan struct{}
+	stopped chan struct{} // Closed once run no longer sends to ch
+	lock    sync.Mutex
+	msg     T
+	full    bool
+}
+
+// NewTypedPublisher creates a new TypedPublisher that reads messages
+// from the given channel.
+//
+// Will return an error if ch is nil.
+func NewTypedPublisher[T any](ch <-chan T) (*TypedPublisher[T], error) {
+	if ch == nil {
+		return nil, errNotChan
+	}
+
+	pub := &TypedPublisher[T]{
+		input: ch,
+		subs:  make(chan typedSub[T]),
+		done:  make(chan struct{}),
+	}
+
+	go pub.main()
+
+	return pub, nil
+}
+
+// Unsubscribe stops the given channel from recieving messages
+// sent through the TypedPublisher. Once it returns, nothing more
+// will be sent to the channel, so it may be closed.
+//
+// Returns an error if the channel was never subscribed to the
+// TypedPublisher.
+func (p *TypedPublisher[T]) Unsubscribe(ch chan<- T) error {
+	return p.request(typedSub[T]{unsub: true, ch: ch})
+}
+
+// Subscribe adds the given channel to the TypedPublisher's list of
+// subscribers and will begin to receive messages sent to the
+// TypedPublisher's input channel.
+//
+// Returns an error if ch is nil, or is already subscribed to the
+// TypedPublisher.
+func (p *TypedPublisher[T]) Subscribe(ch chan<- T) error {
+	if ch == nil {
+		return errNotChan
+	}
+	return p.request(typedSub[T]{ch: ch})
+}
+
+func (p *TypedPublisher[T]) request(s typedSub[T]) error {
+	select {
+	case <-p.done:
+		return errPubDead
+	default:
+	}
+
+	ech := make(chan error)
+	defer close(ech)
+
+	s.err = ech
+	select {
+	case p.subs <- s:
+		return <-ech
+	case <-p.done:
+		return errPubDead
+	}
+}
+
+func (p *TypedPublisher[T]) main() {
+	subscribers := map[chan<- T]*typedOutput[T]{}
+
+	// stop ends every delivery goroutine, and waits for them to exit
+	// before marking the TypedPublisher as dead.
+	stop := func() {
+		for _, out := range subscribers {
+			close(out.quit)
+		}
+		for _, out := range subscribers {
+			<-out.stopped
+		}
+		close(p.done)
+	}
+
+	for {
+		select {
+		case val, ok := <-p.input:
+			if !ok { // input channel closed
+				stop()
+				return
+			}
+
+			// Hand message to all current subscribers
+			for _, out := range subscribers {
+				out.post(val)
+			}
+
+		case subscription := <-p.subs:
+			ch := subscription.ch
+
+			// Take any input that is already waiting first, so a request
+			// made after the input channel was closed reliably sees the
+			// TypedPublisher as dead.
+			select {
+			case val, ok := <-p.input:
+				if !ok {
+					stop()
+					subscription.err <- errPubDead
+					return
+				}
+				for _, out := range subscribers {
+					out.post(val)
+				}
+			default:
+			}
+
+			if subscription.unsub { // Removing a subscription
+				out, ok := subscribers[ch]
+				if !ok { // No match
+					subscription.err <- errSubNone
+					break
+				}
+				close(out.quit)
+				<-out.stopped
+				delete(subscribers, ch)
+			} else {
+				if _, ok := subscribers[ch]; ok { // Already exists
+					subscription.err <- errSubExist
+					break
+				}
+				out := &typedOutput[T]{
+					ch:      ch,
+					notify:  make(chan struct{}, 1),
+					quit:    make(chan struct{}),
+					stopped: make(chan struct{}),
+				}
+				subscribers[ch] = out
+				go out.run()
+			}
+			subscription.err <- nil
+		}
+	}
+}
+
+// post replaces the pending message with v, and wakes the delivery
+// goroutine.
+func (o *typedOutput[T]) post(v T) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	o.msg, o.full = v, true
+	select {
+	case o.notify <- struct{}{}:
+	default:
+	}
+}
+
+// take removes and returns the pending message, if any. Any outstanding
+// wakeup is consumed as well, so a later wakeup always signals a newer
+// message.
+func (o *typedOutput[T]) take() (v T, ok bool) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	var zero T
+	v, ok = o.msg, o.full
+	o.msg, o.full = zero, false
+	select {
+	case <-o.notify:
+	default:
+	}
+	return
+}
+
+func (o *typedOutput[T]) run() {
+	defer close(o.stopped)
+
+	for {
+		val, ok := o.take()
+		if !ok {
+			select {
+			case <-o.notify:
+				continue
+			case <-o.quit:
+				return
+			}
+		}
+
+		select {
+		case o.ch <- val:
+		case <-o.notify: // Superseded by a newer message
+		case <-o.quit:
+			return
+		}
+	}
+}
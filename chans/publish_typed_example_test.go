@@ -0,0 +1,43 @@
+//go:build go1.18
+// +build go1.18
+
+package chans_test
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/cookieo9/go-misc/chans"
+)
+
+func ExampleTypedPublisher() {
+	in := make(chan string)
+	a, b := make(chan string), make(chan string)
+
+	pub, err := chans.NewTypedPublisher(in)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := pub.Subscribe(a); err != nil {
+		log.Fatal(err)
+	}
+	if err := pub.Subscribe(b); err != nil {
+		log.Fatal(err)
+	}
+
+	in <- "hello"
+	fmt.Println(<-a, <-b)
+
+	if err := pub.Unsubscribe(b); err != nil {
+		log.Fatal(err)
+	}
+
+	in <- "world"
+	fmt.Println(<-a)
+	close(in)
+
+	// Output:
+	// hello hello
+	// world
+}
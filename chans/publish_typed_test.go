@@ -0,0 +1,160 @@
+//go:build go1.18
+// +build go1.18
+
+package chans
+
+import (
+	"runtime"
+	"testing"
+)
+
+func newTypedPublisher[T any](x <-chan T, t testing.TB) *TypedPublisher[T] {
+	p, err := NewTypedPublisher(x)
+	if err != nil {
+		t.Fatalf("NewTypedPublisher(%#v): %v", x, err)
+	}
+	return p
+}
+
+func TestTypedPublisherNil(t *testing.T) {
+	if _, err := NewTypedPublisher[int](nil); err != errNotChan {
+		t.Errorf("NewTypedPublisher(nil): expected %q got %q", errNotChan, err)
+	}
+
+	a := make(chan int)
+	defer close(a)
+
+	p := newTypedPublisher(a, t)
+	if err := p.Subscribe(nil); err != errNotChan {
+		t.Errorf("p.Subscribe(nil): expected %q got %q", errNotChan, err)
+	}
+}
+
+func TestTypedPublisherDead(t *testing.T) {
+	a := make(chan int)
+	b := make(chan int)
+
+	p := newTypedPublisher(a, t)
+	close(a)
+
+	if err := p.Subscribe(b); err != errPubDead {
+		t.Fatalf("p.Subscribe(): expected %q, got %q", errPubDead, err)
+	}
+	if err := p.Unsubscribe(b); err != errPubDead {
+		t.Fatalf("p.Unsubscribe(): expected %q, got %q", errPubDead, err)
+	}
+}
+
+func TestTypedPublisherSingleSubscriber(t *testing.T) {
+	a := make(chan int)
+	b := make(chan int)
+	p := newTypedPublisher(a, t)
+	defer close(a)
+
+	for range make([]struct{}, 10) {
+		if err := p.Subscribe(b); err != nil {
+			t.Fatalf("p(%T).Subscribe(%T) -> %v", a, b, err)
+		}
+
+		if err := p.Subscribe(b); err != errSubExist {
+			t.Fatalf("p(%T).Subscribe(%T) -> %v (expected: %v)", a, b, err, errSubExist)
+		}
+
+		if err := p.Unsubscribe(b); err != nil {
+			t.Fatalf("p(%T).Unsubscribe(%T) -> %v", a, b, err)
+		}
+
+		if err := p.Unsubscribe(b); err != errSubNone {
+			t.Fatalf("p(%T).Unsubscribe(%T) -> %v (expected: %v)", a, b, err, errSubNone)
+		}
+	}
+}
+
+func TestTypedPublisherDelivery(t *testing.T) {
+	in := make(chan string)
+	a, b := make(chan string), make(chan string)
+	p := newTypedPublisher(in, t)
+	defer close(in)
+
+	if err := p.Subscribe(a); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Subscribe(b); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, msg := range []string{"foo", "bar", "baz"} {
+		in <- msg
+		// Receive in the opposite order from subscription, to
+		// check subscribers don't wait on each other.
+		if got := <-b; got != msg {
+			t.Errorf("b: expected %q, got %q", msg, got)
+		}
+		if got := <-a; got != msg {
+			t.Errorf("a: expected %q, got %q", msg, got)
+		}
+	}
+}
+
+func TestTypedPublisherUnsubscribeClose(t *testing.T) {
+	in := make(chan int)
+	p := newTypedPublisher(in, t)
+	defer close(in)
+
+	// Unsubscribing a channel with a message still waiting to be
+	// delivered must leave it safe to close, even once there is room
+	// in its buffer.
+	for i := 0; i < 100; i++ {
+		out := make(chan int, 1)
+		if err := p.Subscribe(out); err != nil {
+			t.Fatal(err)
+		}
+		in <- 1
+		for len(out) == 0 {
+			runtime.Gosched()
+		}
+		in <- 2
+
+		if err := p.Unsubscribe(out); err != nil {
+			t.Fatal(err)
+		}
+		<-out
+		close(out)
+		runtime.Gosched()
+	}
+}
+
+func BenchmarkPublisher(b *testing.B) {
+	in, out := make(chan int), make(chan int)
+	p, err := NewPublisher(in)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer close(in)
+
+	if err := p.Subscribe(out); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		in <- i
+		<-out
+	}
+}
+
+func BenchmarkTypedPublisher(b *testing.B) {
+	in, out := make(chan int), make(chan int)
+	p := newTypedPublisher(in, b)
+	defer close(in)
+
+	if err := p.Subscribe(out); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		in <- i
+		<-out
+	}
+}
@@ -0,0 +1,70 @@
+//go:build go1.1
+// +build go1.1
+
+package chans
+
+import (
+	"reflect"
+	"time"
+)
+
+// Replay makes a Publisher remember the last n messages it received,
+// and deliver them to each new subscriber, oldest first, before any
+// messages which arrive after it subscribed. With n of 1, a new
+// subscriber starts with the latest value sent to the Publisher.
+//
+// Replayed messages are delivered at the subscriber's own pace, and
+// don't hold up the input or other subscribers, whatever the
+// subscriber's delivery Policy. A Router only replays the messages
+// whose topic matches the new subscriber.
+func Replay(n int) PublisherOption {
+	return func(p *Publisher) {
+		if n > 0 {
+			p.history.ring = make([]historyEntry, n)
+		}
+	}
+}
+
+// A history is a ring buffer of the most recent messages
+// received by a Publisher.
+type history struct {
+	ring  []historyEntry
+	next  int
+	count int
+}
+
+type historyEntry struct {
+	msg   reflect.Value
+	topic string
+}
+
+// record adds a message to the history, forgetting the oldest one if
+// the history is full.
+func (h *history) record(msg reflect.Value, topic string) {
+	if len(h.ring) == 0 {
+		return
+	}
+
+	h.ring[h.next] = historyEntry{msg, topic}
+	h.next = (h.next + 1) % len(h.ring)
+	if h.count < len(h.ring) {
+		h.count++
+	}
+}
+
+// replay queues the remembered messages the output is interested
+// in, oldest first.
+func (h *history) replay(o *output) {
+	o.replayAt = time.Now()
+	start := h.next - h.count
+	if start < 0 {
+		start += len(h.ring)
+	}
+
+	for i := 0; i < h.count; i++ {
+		entry := h.ring[(start+i)%len(h.ring)]
+		if o.match == nil || o.match(entry.msg, entry.topic) {
+			o.replay = append(o.replay, entry.msg)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+//go:build go1.1
+// +build go1.1
+
+package chans
+
+import (
+	"testing"
+)
+
+func receiveAll(t *testing.T, out chan int, expect ...int) {
+	for _, e := range expect {
+		if got := <-out; got != e {
+			t.Errorf("expected %d, got %d", e, got)
+		}
+	}
+}
+
+func TestReplay(t *testing.T) {
+	in := make(chan int)
+	p, err := NewPublisher(in, Replay(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer close(in)
+
+	live := make(chan int)
+	if err := p.Subscribe(live); err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 5; i++ {
+		in <- i
+		receiveAll(t, live, i)
+	}
+
+	late, slow := make(chan int), make(chan int)
+	if err := p.Subscribe(late); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Subscribe(slow, WithPolicy(Block)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Neither late joiner holds up the input while catching up,
+	// even with the Block policy.
+	in <- 6
+	receiveAll(t, live, 6)
+	receiveAll(t, late, 3, 4, 5, 6)
+	receiveAll(t, slow, 3, 4, 5, 6)
+
+	in <- 7
+	receiveAll(t, live, 7)
+	receiveAll(t, late, 7)
+	receiveAll(t, slow, 7)
+}
+
+func TestReplayLatest(t *testing.T) {
+	in := make(chan int)
+	p, err := NewPublisher(in, Replay(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer close(in)
+
+	out := make(chan int)
+	if err := p.Subscribe(out); err != nil {
+		t.Fatal(err)
+	}
+	in <- 1
+	in <- 2
+	receiveAll(t, out, 2)
+
+	for i := 0; i < 3; i++ {
+		late := make(chan int)
+		if err := p.Subscribe(late); err != nil {
+			t.Fatal(err)
+		}
+		receiveAll(t, late, 2)
+	}
+}
+
+func TestReplayRouter(t *testing.T) {
+	in := make(chan event)
+	r, err := NewRouter(in, eventTopic, Replay(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer close(in)
+
+	for i, topic := range []string{"a", "b", "a", "b"} {
+		in <- event{topic, i}
+	}
+
+	out := make(chan event)
+	if err := r.Subscribe(out, "b"); err != nil {
+		t.Fatal(err)
+	}
+	for _, expect := range []event{{"b", 1}, {"b", 3}} {
+		if got := <-out; got != expect {
+			t.Errorf("expected %v, got %v", expect, got)
+		}
+	}
+	checkDropped(t, r.pub, out, 0)
+}
@@ -0,0 +1,199 @@
+//go:build go1.1
+// +build go1.1
+
+package chans
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+var errBadPattern = errors.New("bad topic pattern")
+
+// A Router is a Publisher which only delivers each message to the
+// subscribers interested in it, rather than to every subscriber.
+//
+// Every message has a topic, given by the Router's topic function.
+// Topics are made of segments separated by dots, eg: "orders.eu.created".
+// Subscribers register with a pattern which is compared to the topic
+// segment by segment, where:
+//   - "*" matches any single segment
+//   - "#" as the last segment matches zero or more remaining segments
+//   - anything else must match the segment exactly
+//
+// So "orders.*.created" matches "orders.eu.created" but not
+// "orders.created", and "orders.#" matches both.
+//
+// Messages are delivered by the same loop as a Publisher, so delivery
+// policies, cancellation, and closing subscribers behave the same way.
+type Router struct {
+	pub      *Publisher
+	lock     sync.Mutex
+	patterns map[interface{}]string
+}
+
+// NewRouter creates a new Router that reads messages from the given
+// channel, and uses topic to find the topic of each message. The topic
+// function is called once per message, from the Router's goroutine.
+//
+// Will return an error if the ch argument is not a channel or
+// cannot be received from.
+func NewRouter(ch interface{}, topic func(msg interface{}) string, opts ...PublisherOption) (*Router, error) {
+	opts = append(append([]PublisherOption(nil), opts...), func(p *Publisher) {
+		p.topic = topic
+	})
+
+	pub, err := NewPublisher(ch, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Router{
+		pub:      pub,
+		patterns: map[interface{}]string{},
+	}, nil
+}
+
+// Subscribe adds the given channel to the Router's subscribers, where
+// it will receive each message whose topic matches pattern.
+//
+// Returns an error if the pattern is malformed, or for any of the
+// reasons given by Publisher.Subscribe.
+func (r *Router) Subscribe(ch interface{}, pattern string, opts ...SubscribeOption) error {
+	segments := strings.Split(pattern, ".")
+	if !validPattern(segments) {
+		return errBadPattern
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	opts = append(append([]SubscribeOption(nil), opts...), matching(func(_ reflect.Value, topic string) bool {
+		return matchTopic(segments, topic)
+	}))
+	if err := r.pub.Subscribe(ch, opts...); err != nil {
+		return err
+	}
+
+	r.patterns[ch] = pattern
+	return nil
+}
+
+// SubscribeFunc adds the given channel to the Router's subscribers,
+// where it will receive each message for which match returns true.
+// The match function is called from the Router's goroutine.
+//
+// Channels subscribed through SubscribeFunc are not included by
+// Count or Topics.
+func (r *Router) SubscribeFunc(ch interface{}, match func(msg interface{}) bool, opts ...SubscribeOption) error {
+	opts = append(append([]SubscribeOption(nil), opts...), matching(func(msg reflect.Value, _ string) bool {
+		return match(msg.Interface())
+	}))
+	return r.pub.Subscribe(ch, opts...)
+}
+
+// Unsubscribe stops the given channel from recieving messages
+// sent through the Router.
+//
+// Returns an error if the channel was never subscribed to the
+// Router.
+func (r *Router) Unsubscribe(ch interface{}) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if err := r.pub.Unsubscribe(ch); err != nil {
+		return err
+	}
+
+	delete(r.patterns, ch)
+	return nil
+}
+
+// Dropped returns the number of messages the Router has discarded
+// instead of delivering them to the given channel.
+func (r *Router) Dropped(ch interface{}) (uint64, error) {
+	return r.pub.Dropped(ch)
+}
+
+// Stats returns a snapshot of the Router's activity so far.
+func (r *Router) Stats() (Stats, error) {
+	return r.pub.Stats()
+}
+
+// Count returns the number of subscribers which would receive a
+// message with the given topic.
+func (r *Router) Count(topic string) int {
+	n := 0
+	for pattern, subs := range r.Topics() {
+		if matchTopic(strings.Split(pattern, "."), topic) {
+			n += subs
+		}
+	}
+	return n
+}
+
+// Topics returns the patterns subscribed to the Router, along with
+// the number of subscribers for each.
+func (r *Router) Topics() map[string]int {
+	counts := map[string]int{}
+	if r.pub.Err() != nil {
+		return counts
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for _, pattern := range r.patterns {
+		counts[pattern]++
+	}
+	return counts
+}
+
+// Done returns a channel which is closed once the Router has
+// stopped, and will no longer send to any subscriber.
+func (r *Router) Done() <-chan struct{} {
+	return r.pub.Done()
+}
+
+// Err returns nil while the Router is running. Once Done is
+// closed, it returns the reason the Router stopped.
+func (r *Router) Err() error {
+	return r.pub.Err()
+}
+
+func validPattern(segments []string) bool {
+	for i, segment := range segments {
+		if segment == "#" && i != len(segments)-1 {
+			return false
+		}
+	}
+	return true
+}
+
+// matchTopic reports whether topic matches the pattern split into
+// segments. It is called for every message and subscriber, so it walks
+// the topic in place rather than splitting it.
+func matchTopic(segments []string, topic string) bool {
+	rest, more := topic, true
+	for _, segment := range segments {
+		if segment == "#" {
+			return true
+		}
+		if !more {
+			return false
+		}
+
+		head := rest
+		if i := strings.IndexByte(rest, '.'); i >= 0 {
+			head, rest = rest[:i], rest[i+1:]
+		} else {
+			more = false
+		}
+		if segment != "*" && segment != head {
+			return false
+		}
+	}
+	return !more
+}
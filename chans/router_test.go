@@ -0,0 +1,120 @@
+//go:build go1.1
+// +build go1.1
+
+package chans
+
+import (
+	"strings"
+	"testing"
+)
+
+var matchTopicTests = []struct {
+	pattern, topic string
+	match          bool
+}{
+	{"orders", "orders", true},
+	{"orders", "order", false},
+	{"orders", "orders.created", false},
+	{"orders.created", "orders", false},
+	{"orders.*", "orders.created", true},
+	{"orders.*", "orders", false},
+	{"orders.*", "orders.eu.created", false},
+	{"orders.*.created", "orders.eu.created", true},
+	{"orders.*.created", "orders.eu.deleted", false},
+	{"orders.#", "orders", true},
+	{"orders.#", "orders.created", true},
+	{"orders.#", "orders.eu.created", true},
+	{"orders.#", "users.created", false},
+	{"#", "anything.at.all", true},
+	{"*.created", "users.created", true},
+	{"orders..created", "orders..created", true},
+	{"orders.*", "orders.", true},
+}
+
+func TestMatchTopic(t *testing.T) {
+	for _, test := range matchTopicTests {
+		if got := matchTopic(strings.Split(test.pattern, "."), test.topic); got != test.match {
+			t.Errorf("matchTopic(%q, %q): expected %v, got %v", test.pattern, test.topic, test.match, got)
+		}
+	}
+}
+
+type event struct {
+	Topic string
+	N     int
+}
+
+func eventTopic(msg interface{}) string {
+	return msg.(event).Topic
+}
+
+func TestRouter(t *testing.T) {
+	in := make(chan event)
+	r, err := NewRouter(in, eventTopic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer close(in)
+
+	all, created, even := make(chan event), make(chan event), make(chan event)
+	if err := r.Subscribe(all, "orders.#"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Subscribe(created, "orders.*.created"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.SubscribeFunc(even, func(msg interface{}) bool { return msg.(event).N%2 == 0 }); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		msg  event
+		outs []chan event
+	}{
+		{event{"orders", 1}, []chan event{all}},
+		{event{"orders.eu.created", 2}, []chan event{all, created, even}},
+		{event{"orders.eu.deleted", 3}, []chan event{all}},
+		{event{"users.created", 4}, []chan event{even}},
+		{event{"users.deleted", 5}, nil},
+	}
+	for _, test := range tests {
+		in <- test.msg
+		for _, out := range test.outs {
+			if got := <-out; got != test.msg {
+				t.Errorf("expected %v, got %v", test.msg, got)
+			}
+		}
+	}
+
+	// Any message routed to the wrong subscriber would be displaced
+	// by a later one, and counted as dropped.
+	for _, out := range []chan event{all, created, even} {
+		checkDropped(t, r.pub, out, 0)
+	}
+
+	if n := r.Count("orders.us.created"); n != 2 {
+		t.Errorf("r.Count(): expected 2, got %d", n)
+	}
+	if err := r.Unsubscribe(all); err != nil {
+		t.Fatal(err)
+	}
+	if n := r.Count("orders.us.created"); n != 1 {
+		t.Errorf("r.Count(): expected 1, got %d", n)
+	}
+	if topics := r.Topics(); len(topics) != 1 || topics["orders.*.created"] != 1 {
+		t.Errorf("r.Topics(): expected map[orders.*.created:1], got %v", topics)
+	}
+}
+
+func TestRouterBadPattern(t *testing.T) {
+	in := make(chan event)
+	r, err := NewRouter(in, eventTopic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer close(in)
+
+	if err := r.Subscribe(make(chan event), "orders.#.created"); err != errBadPattern {
+		t.Errorf("r.Subscribe(): expected %q, got %q", errBadPattern, err)
+	}
+}
@@ -0,0 +1,182 @@
+//go:build go1.7
+// +build go1.7
+
+package chans
+
+import (
+	"context"
+	"sync"
+)
+
+// A Scatter sends requests to a group of responders through a Publisher,
+// and gathers their replies.
+//
+// Responders join with a channel on which they receive each Request,
+// and answer it with Request.Reply. A responder which is not ready
+// when a new request arrives has its older request replaced, as with
+// the DropOldest delivery Policy, and is reported as missing from that
+// older request.
+type Scatter struct {
+	pub     *Publisher
+	input   chan Request
+	cancel  context.CancelFunc
+	lock    sync.Mutex
+	members map[chan<- Request]bool
+}
+
+// A Request is the message received by the responders of a Scatter.
+type Request struct {
+	Body interface{}
+
+	replies chan<- Reply
+	done    <-chan struct{}
+}
+
+// A Reply is an answer to a Request, gathered by Scatter.Gather.
+type Reply struct {
+	From  chan<- Request // The channel the responder joined with
+	Value interface{}
+}
+
+// NewScatter creates a new Scatter, with no responders.
+func NewScatter() *Scatter {
+	ctx, cancel := context.WithCancel(context.Background())
+	input := make(chan Request)
+	pub, err := NewPublisherContext(ctx, input, CloseSubscribers())
+	if err != nil {
+		panic(err)
+	}
+
+	return &Scatter{
+		pub:     pub,
+		input:   input,
+		cancel:  cancel,
+		members: map[chan<- Request]bool{},
+	}
+}
+
+// Join adds ch to the responders, which will receive every Request
+// gathered from now on.
+func (s *Scatter) Join(ch chan<- Request) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := s.pub.Subscribe(ch); err != nil {
+		return s.closed(err)
+	}
+	s.members[ch] = true
+	return nil
+}
+
+// Leave removes ch from the responders.
+func (s *Scatter) Leave(ch chan<- Request) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := s.pub.Unsubscribe(ch); err != nil {
+		return s.closed(err)
+	}
+	delete(s.members, ch)
+	return nil
+}
+
+// Close stops the Scatter, and closes the channels of its responders.
+// Further calls to Gather, Join and Leave will fail.
+func (s *Scatter) Close() {
+	s.cancel()
+	<-s.pub.Done()
+}
+
+// Gather sends a Request carrying body to all current responders, and
+// collects their replies. It returns once k replies have arrived, or
+// every responder has replied if k is not positive, or ctx is done,
+// whichever is first.
+//
+// Gather returns the replies in the order they arrived, along with the
+// responders which hadn't replied. Only the first reply from each
+// responder is counted. The error is nil if enough replies arrived,
+// or the error from ctx if it was done first.
+func (s *Scatter) Gather(ctx context.Context, body interface{}, k int) ([]Reply, []chan<- Request, error) {
+	s.lock.Lock()
+	waiting := make(map[chan<- Request]bool, len(s.members))
+	for member := range s.members {
+		waiting[member] = true
+	}
+
+	replies := make(chan Reply, len(waiting))
+	done := make(chan struct{})
+	defer close(done)
+
+	err := s.send(ctx, Request{
+		Body:    body,
+		replies: replies,
+		done:    done,
+	})
+	s.lock.Unlock()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if k <= 0 || k > len(waiting) {
+		k = len(waiting)
+	}
+
+	var gathered []Reply
+	for len(gathered) < k && err == nil {
+		select {
+		case reply := <-replies:
+			if waiting[reply.From] {
+				delete(waiting, reply.From)
+				gathered = append(gathered, reply)
+			}
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+	}
+
+	missing := make([]chan<- Request, 0, len(waiting))
+	for member := range waiting {
+		missing = append(missing, member)
+	}
+	return gathered, missing, err
+}
+
+// closed returns ErrClosed in place of err if the Scatter has been
+// closed, rather than the cancellation which stopped its Publisher.
+func (s *Scatter) closed(err error) error {
+	select {
+	case <-s.pub.Done():
+		return ErrClosed
+	default:
+		return err
+	}
+}
+
+func (s *Scatter) send(ctx context.Context, req Request) error {
+	select {
+	case s.input <- req:
+		return nil
+	case <-s.pub.Done():
+		return errPubDead
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Reply answers the Request on behalf of the responder which joined
+// with the channel from. It reports whether the reply was accepted;
+// replies are refused once the Gather call has returned.
+func (r Request) Reply(from chan<- Request, value interface{}) bool {
+	select {
+	case <-r.done:
+		return false
+	default:
+	}
+
+	select {
+	case r.replies <- Reply{From: from, Value: value}:
+		return true
+	case <-r.done:
+		return false
+	}
+}
@@ -0,0 +1,121 @@
+//go:build go1.7
+// +build go1.7
+
+package chans
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// respond answers each Request received on ch with the result of f,
+// until ch is closed.
+func respond(ch chan Request, f func(body interface{}) interface{}) {
+	for req := range ch {
+		req.Reply(ch, f(req.Body))
+	}
+}
+
+func double(body interface{}) interface{} {
+	return body.(int) * 2
+}
+
+func TestScatterGatherAll(t *testing.T) {
+	s := NewScatter()
+	defer s.Close()
+
+	members := []chan Request{make(chan Request), make(chan Request), make(chan Request)}
+	for _, ch := range members {
+		if err := s.Join(ch); err != nil {
+			t.Fatal(err)
+		}
+		go respond(ch, double)
+	}
+
+	replies, missing, err := s.Gather(context.Background(), 21, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(missing) != 0 {
+		t.Errorf("expected no missing responders, got %d", len(missing))
+	}
+	if len(replies) != len(members) {
+		t.Fatalf("expected %d replies, got %d", len(members), len(replies))
+	}
+	seen := map[chan<- Request]bool{}
+	for _, reply := range replies {
+		if reply.Value != 42 {
+			t.Errorf("expected 42 from %v, got %v", reply.From, reply.Value)
+		}
+		seen[reply.From] = true
+	}
+	if len(seen) != len(members) {
+		t.Errorf("expected a reply from each of %d responders, got %d", len(members), len(seen))
+	}
+}
+
+func TestScatterGatherDeadline(t *testing.T) {
+	s := NewScatter()
+	defer s.Close()
+
+	fast, silent := make(chan Request), make(chan Request, 1)
+	for _, ch := range []chan Request{fast, silent} {
+		if err := s.Join(ch); err != nil {
+			t.Fatal(err)
+		}
+	}
+	go respond(fast, double)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	replies, missing, err := s.Gather(ctx, 1, 0)
+	if err != context.DeadlineExceeded {
+		t.Errorf("expected %q, got %q", context.DeadlineExceeded, err)
+	}
+	if len(replies) != 1 || replies[0].From != fast || replies[0].Value != 2 {
+		t.Errorf("expected one reply of 2 from fast, got %v", replies)
+	}
+	if len(missing) != 1 || missing[0] != silent {
+		t.Errorf("expected silent to be missing, got %v", missing)
+	}
+
+	// Replies after Gather returns are refused.
+	if req := <-silent; req.Reply(silent, 0) {
+		t.Error("late reply was accepted")
+	}
+}
+
+func TestScatterGatherFirst(t *testing.T) {
+	s := NewScatter()
+
+	var members []chan Request
+	for i := 0; i < 5; i++ {
+		ch := make(chan Request)
+		if err := s.Join(ch); err != nil {
+			t.Fatal(err)
+		}
+		go respond(ch, double)
+		members = append(members, ch)
+	}
+
+	replies, missing, err := s.Gather(context.Background(), 5, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(replies) != 2 || len(missing) != 3 {
+		t.Errorf("expected 2 replies and 3 missing, got %d and %d", len(replies), len(missing))
+	}
+
+	if err := s.Leave(members[0]); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+	if _, _, err := s.Gather(context.Background(), 5, 0); err != errPubDead {
+		t.Errorf("Gather after Close: expected %q, got %q", errPubDead, err)
+	}
+	if err := s.Join(make(chan Request)); err != errPubDead {
+		t.Errorf("Join after Close: expected %q, got %q", errPubDead, err)
+	}
+}
@@ -0,0 +1,249 @@
+//go:build go1.18
+// +build go1.18
+
+package chans
+
+import (
+	"context"
+	"time"
+)
+
+// The stage functions below each start a goroutine which reads from
+// an input channel, and returns a new channel carrying the transformed
+// stream. The returned channel is closed once the input is closed and
+// everything has been sent, or as soon as ctx is done, in which case
+// any values held by the stage are discarded.
+
+// send sends v to out, unless ctx is done first. It reports whether
+// v was sent.
+func send[T any](ctx context.Context, out chan<- T, v T) bool {
+	select {
+	case out <- v:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Map sends f(v) for each value v received from in.
+func Map[T, U any](ctx context.Context, in <-chan T, f func(T) U) <-chan U {
+	out := make(chan U)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case v, ok := <-in:
+				if !ok || !send(ctx, out, f(v)) {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Filter sends each value v received from in for which f(v) is true.
+func Filter[T any](ctx context.Context, in <-chan T, f func(T) bool) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case v, ok := <-in:
+				if !ok {
+					return
+				}
+				if f(v) && !send(ctx, out, v) {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Batch groups the values received from in into slices of n values.
+// If maxWait is positive, a smaller batch is sent once maxWait has
+// passed since its first value was received. A final, smaller batch
+// is sent when in is closed.
+//
+// Panics if n is not positive.
+func Batch[T any](ctx context.Context, in <-chan T, n int, maxWait time.Duration) <-chan []T {
+	if n <= 0 {
+		panic("chans: Batch size must be positive")
+	}
+
+	clk := stageClock
+	out := make(chan []T)
+	go func() {
+		defer close(out)
+
+		var (
+			batch   []T
+			timeout <-chan time.Time
+		)
+		flush := func() bool {
+			full := batch
+			batch, timeout = nil, nil
+			return send(ctx, out, full)
+		}
+
+		for {
+			select {
+			case v, ok := <-in:
+				if !ok {
+					if len(batch) > 0 {
+						flush()
+					}
+					return
+				}
+
+				if batch == nil {
+					batch = make([]T, 0, n)
+					if maxWait > 0 {
+						timeout = clk.After(maxWait)
+					}
+				}
+				batch = append(batch, v)
+				if len(batch) == n && !flush() {
+					return
+				}
+
+			case <-timeout:
+				if !flush() {
+					return
+				}
+
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Window sends slices holding the last size values received from in,
+// once every step values. With step equal to size, each value is in
+// exactly one window (tumbling windows). With step less than size, the
+// windows overlap (sliding windows). Values received after the last
+// complete window when in is closed are discarded.
+//
+// Panics if size or step is not positive.
+func Window[T any](ctx context.Context, in <-chan T, size, step int) <-chan []T {
+	if size <= 0 || step <= 0 {
+		panic("chans: Window size and step must be positive")
+	}
+
+	out := make(chan []T)
+	go func() {
+		defer close(out)
+
+		window := make([]T, 0, size)
+		count := 0
+		for {
+			select {
+			case v, ok := <-in:
+				if !ok {
+					return
+				}
+
+				if len(window) == size {
+					copy(window, window[1:])
+					window = window[:size-1]
+				}
+				window = append(window, v)
+				count++
+
+				if len(window) == size && count >= step {
+					count = 0
+					if !send(ctx, out, append([]T(nil), window...)) {
+						return
+					}
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Throttle sends the values received from in, waiting as needed so
+// that at least interval passes between each value sent. Values are
+// never dropped; instead, the input is read more slowly.
+func Throttle[T any](ctx context.Context, in <-chan T, interval time.Duration) <-chan T {
+	clk := stageClock
+	out := make(chan T)
+	go func() {
+		defer close(out)
+
+		var next time.Time
+		for {
+			select {
+			case v, ok := <-in:
+				if !ok {
+					return
+				}
+
+				if wait := next.Sub(clk.Now()); !next.IsZero() && wait > 0 {
+					select {
+					case <-clk.After(wait):
+					case <-ctx.Done():
+						return
+					}
+				}
+				if !send(ctx, out, v) {
+					return
+				}
+				next = clk.Now().Add(interval)
+
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Debounce sends a value received from in only once quiet has passed
+// without another value arriving, so a burst of values results in
+// only its last value being sent. A value still waiting when in is
+// closed is sent straight away.
+func Debounce[T any](ctx context.Context, in <-chan T, quiet time.Duration) <-chan T {
+	clk := stageClock
+	out := make(chan T)
+	go func() {
+		defer close(out)
+
+		var (
+			latest  T
+			timeout <-chan time.Time
+		)
+		for {
+			select {
+			case v, ok := <-in:
+				if !ok {
+					if timeout != nil {
+						send(ctx, out, latest)
+					}
+					return
+				}
+				latest, timeout = v, clk.After(quiet)
+
+			case <-timeout:
+				timeout = nil
+				if !send(ctx, out, latest) {
+					return
+				}
+
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,248 @@
+//go:build go1.18
+// +build go1.18
+
+package chans
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// A fakeClock only moves forward when told to by Advance.
+type fakeClock struct {
+	lock    sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+type fakeWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	c := &fakeClock{now: time.Unix(0, 0)}
+	c.cond = sync.NewCond(&c.lock)
+	return c
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, fakeWaiter{c.now.Add(d), ch})
+	c.cond.Broadcast()
+	return ch
+}
+
+// Advance moves the clock forward by d, firing any timers which
+// become due.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.now = c.now.Add(d)
+	waiters := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(c.now) {
+			waiters = append(waiters, w)
+			continue
+		}
+		w.ch <- c.now
+	}
+	c.waiters = waiters
+}
+
+// BlockUntil waits until at least n timers are waiting to fire.
+func (c *fakeClock) BlockUntil(n int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for len(c.waiters) < n {
+		c.cond.Wait()
+	}
+}
+
+// useClock makes stages started from now on use c, until the returned
+// function is called.
+func useClock(c clock) func() {
+	stageClock = c
+	return func() { stageClock = realClock{} }
+}
+
+func feed[T any](values ...T) <-chan T {
+	in := make(chan T)
+	go func() {
+		defer close(in)
+		for _, v := range values {
+			in <- v
+		}
+	}()
+	return in
+}
+
+func collect[T any](out <-chan T) []T {
+	var values []T
+	for v := range out {
+		values = append(values, v)
+	}
+	return values
+}
+
+func expectNothing[T any](t *testing.T, out <-chan T) {
+	t.Helper()
+	select {
+	case v, ok := <-out:
+		t.Fatalf("expected nothing to receive, got %v (%v)", v, ok)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func TestMapFilter(t *testing.T) {
+	ctx := context.Background()
+	even := Filter(ctx, feed(1, 2, 3, 4, 5, 6), func(x int) bool { return x%2 == 0 })
+	out := collect(Map(ctx, even, strconv.Itoa))
+
+	if expect := []string{"2", "4", "6"}; !reflect.DeepEqual(out, expect) {
+		t.Errorf("expected %q, got %q", expect, out)
+	}
+}
+
+func TestStageCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	out := Map(ctx, make(chan int), func(x int) int { return x })
+
+	cancel()
+	if _, ok := <-out; ok {
+		t.Error("expected closed channel after cancel")
+	}
+}
+
+var windowTests = []struct {
+	size, step int
+	expect     [][]int
+}{
+	{2, 2, [][]int{{1, 2}, {3, 4}}},
+	{3, 1, [][]int{{1, 2, 3}, {2, 3, 4}, {3, 4, 5}}},
+	{3, 2, [][]int{{1, 2, 3}, {3, 4, 5}}},
+	{2, 3, [][]int{{2, 3}}},
+	{6, 1, nil},
+}
+
+func TestWindow(t *testing.T) {
+	for _, test := range windowTests {
+		out := collect(Window(context.Background(), feed(1, 2, 3, 4, 5), test.size, test.step))
+		if !reflect.DeepEqual(out, test.expect) {
+			t.Errorf("Window(%d, %d): expected %v, got %v", test.size, test.step, test.expect, out)
+		}
+	}
+}
+
+func TestBatch(t *testing.T) {
+	clock := newFakeClock()
+	defer useClock(clock)()
+	ctx := context.Background()
+	in := make(chan int)
+	out := Batch(ctx, in, 3, time.Second)
+
+	// A full batch is sent right away.
+	for i := 1; i <= 3; i++ {
+		in <- i
+	}
+	if got := <-out; !reflect.DeepEqual(got, []int{1, 2, 3}) {
+		t.Errorf("expected [1 2 3], got %v", got)
+	}
+
+	// A partial batch waits for maxWait.
+	in <- 4
+	in <- 5
+	clock.BlockUntil(2)
+	clock.Advance(999 * time.Millisecond)
+	expectNothing(t, out)
+	clock.Advance(time.Millisecond)
+	if got := <-out; !reflect.DeepEqual(got, []int{4, 5}) {
+		t.Errorf("expected [4 5], got %v", got)
+	}
+
+	// The remainder is sent when the input closes.
+	in <- 6
+	close(in)
+	if got := collect(out); !reflect.DeepEqual(got, [][]int{{6}}) {
+		t.Errorf("expected [[6]], got %v", got)
+	}
+}
+
+func TestThrottle(t *testing.T) {
+	clock := newFakeClock()
+	defer useClock(clock)()
+	ctx := context.Background()
+	in := make(chan int)
+	out := Throttle(ctx, in, time.Second)
+
+	in <- 1
+	if got := <-out; got != 1 {
+		t.Errorf("expected 1, got %d", got)
+	}
+
+	in <- 2
+	clock.BlockUntil(1)
+	expectNothing(t, out)
+	clock.Advance(time.Second)
+	if got := <-out; got != 2 {
+		t.Errorf("expected 2, got %d", got)
+	}
+
+	// Once the interval has passed, values go straight through.
+	clock.Advance(2 * time.Second)
+	in <- 3
+	if got := <-out; got != 3 {
+		t.Errorf("expected 3, got %d", got)
+	}
+	close(in)
+	if _, ok := <-out; ok {
+		t.Error("expected closed channel")
+	}
+}
+
+func TestDebounce(t *testing.T) {
+	clock := newFakeClock()
+	defer useClock(clock)()
+	ctx := context.Background()
+	in := make(chan int)
+	out := Debounce(ctx, in, time.Second)
+
+	in <- 1
+	clock.BlockUntil(1)
+	clock.Advance(500 * time.Millisecond)
+	in <- 2
+	clock.BlockUntil(2)
+	clock.Advance(500 * time.Millisecond)
+	expectNothing(t, out)
+
+	clock.Advance(500 * time.Millisecond)
+	if got := <-out; got != 2 {
+		t.Errorf("expected 2, got %d", got)
+	}
+
+	in <- 3
+	close(in)
+	if got := collect(out); !reflect.DeepEqual(got, []int{3}) {
+		t.Errorf("expected [3], got %v", got)
+	}
+}
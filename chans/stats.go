@@ -0,0 +1,99 @@
+//go:build go1.1
+// +build go1.1
+
+package chans
+
+import (
+	"time"
+)
+
+// Stats is a snapshot of the activity of a Publisher.
+type Stats struct {
+	Received    uint64            // Messages received from the input channel
+	Subscribers []SubscriberStats // One entry per current subscriber
+	InFlight    int               // Messages waiting to be delivered, over all subscribers
+	MaxLatency  time.Duration     // Longest time taken to deliver a message, over all subscribers
+}
+
+// SubscriberStats is a snapshot of the deliveries made by a Publisher
+// to one of its subscribers.
+type SubscriberStats struct {
+	Channel    interface{}   // The subscribed channel
+	Policy     Policy        // The subscriber's delivery Policy
+	Delivered  uint64        // Messages delivered, including replayed ones
+	Dropped    uint64        // Messages discarded under the delivery Policy
+	Pending    int           // Messages waiting to be delivered
+	MaxLatency time.Duration // Longest time taken to deliver a message
+}
+
+// A Hook is notified by a Publisher as subscribers come and go, and
+// as messages are delivered to them or dropped.
+//
+// Hook methods are called from the Publisher's goroutine, so they
+// hold up the Publisher while they run, and must not call any of the
+// Publisher's methods.
+type Hook interface {
+	Subscribed(ch interface{})
+	Unsubscribed(ch interface{})
+	Delivered(ch interface{}, latency time.Duration)
+	Dropped(ch interface{})
+}
+
+type nopHook struct{}
+
+func (nopHook) Subscribed(interface{})               {}
+func (nopHook) Unsubscribed(interface{})             {}
+func (nopHook) Delivered(interface{}, time.Duration) {}
+func (nopHook) Dropped(interface{})                  {}
+
+// WithHook makes a Publisher report its activity to hook.
+//
+// A subscriber is reported as Unsubscribed whenever the Publisher
+// lets go of it, including when the Publisher stops.
+func WithHook(hook Hook) PublisherOption {
+	return func(p *Publisher) {
+		p.hook = hook
+	}
+}
+
+// Stats returns a snapshot of the Publisher's activity so far.
+//
+// Returns an error if the Publisher has stopped.
+func (p *Publisher) Stats() (Stats, error) {
+	var stats Stats
+	err := p.request(sub{
+		op:    opStats,
+		stats: &stats,
+	})
+	return stats, err
+}
+
+func (p *Publisher) snapshot(subscribers []*output) Stats {
+	stats := Stats{
+		Received:    p.received,
+		Subscribers: make([]SubscriberStats, 0, len(subscribers)),
+	}
+
+	for _, subscriber := range subscribers {
+		pending := len(subscriber.replay)
+		if subscriber.full {
+			pending++
+		}
+
+		stats.InFlight += pending
+		if subscriber.maxLatency > stats.MaxLatency {
+			stats.MaxLatency = subscriber.maxLatency
+		}
+
+		stats.Subscribers = append(stats.Subscribers, SubscriberStats{
+			Channel:    subscriber.key,
+			Policy:     subscriber.policy,
+			Delivered:  subscriber.delivered,
+			Dropped:    subscriber.dropped,
+			Pending:    pending,
+			MaxLatency: subscriber.maxLatency,
+		})
+	}
+
+	return stats
+}
@@ -0,0 +1,105 @@
+//go:build go1.1
+// +build go1.1
+
+package chans
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// A recordingHook remembers the calls made to it, in order.
+type recordingHook struct {
+	lock   sync.Mutex
+	names  map[interface{}]string
+	events []string
+}
+
+func (h *recordingHook) record(ch interface{}, event string) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.events = append(h.events, fmt.Sprintf("%s %s", event, h.names[ch]))
+}
+
+func (h *recordingHook) Subscribed(ch interface{})   { h.record(ch, "subscribed") }
+func (h *recordingHook) Unsubscribed(ch interface{}) { h.record(ch, "unsubscribed") }
+func (h *recordingHook) Dropped(ch interface{})      { h.record(ch, "dropped") }
+func (h *recordingHook) Delivered(ch interface{}, latency time.Duration) {
+	h.record(ch, "delivered")
+}
+
+func (h *recordingHook) Events() []string {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return append([]string(nil), h.events...)
+}
+
+func TestPublisherStats(t *testing.T) {
+	in := make(chan int)
+	a, b := make(chan int), make(chan int)
+	hook := &recordingHook{names: map[interface{}]string{a: "a", b: "b"}}
+
+	p, err := NewPublisher(in, WithHook(hook))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.Subscribe(a); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Subscribe(b, WithPolicy(DropNewest)); err != nil {
+		t.Fatal(err)
+	}
+
+	in <- 1
+	receiveAll(t, a, 1)
+	in <- 2
+	receiveAll(t, a, 2)
+
+	stats, err := p.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	latency := stats.Subscribers[0].MaxLatency
+	expect := Stats{
+		Received: 2,
+		Subscribers: []SubscriberStats{
+			{Channel: a, Policy: DropOldest, Delivered: 2, MaxLatency: latency},
+			{Channel: b, Policy: DropNewest, Dropped: 1, Pending: 1},
+		},
+		InFlight:   1,
+		MaxLatency: latency,
+	}
+	if !reflect.DeepEqual(stats, expect) {
+		t.Errorf("p.Stats(): expected %+v, got %+v", expect, stats)
+	}
+
+	if err := p.Unsubscribe(a); err != nil {
+		t.Fatal(err)
+	}
+	receiveAll(t, b, 1)
+	close(in)
+	<-p.Done()
+
+	if _, err := p.Stats(); err != errPubDead {
+		t.Errorf("p.Stats(): expected %q, got %q", errPubDead, err)
+	}
+
+	events := hook.Events()
+	expectEvents := []string{
+		"subscribed a",
+		"subscribed b",
+		"delivered a",
+		"dropped b",
+		"delivered a",
+		"unsubscribed a",
+		"delivered b",
+		"unsubscribed b",
+	}
+	if !reflect.DeepEqual(events, expectEvents) {
+		t.Errorf("hook events: expected %q, got %q", expectEvents, events)
+	}
+}
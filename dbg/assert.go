@@ -0,0 +1,77 @@
+package dbg
+
+import (
+	"fmt"
+	"log"
+	"runtime"
+)
+
+// AssertPanics chooses what happens when an assertion made by Assert or
+// Invariant fails: if true, it panics with an *AssertionError, and if
+// false, it logs the failure along with a stack trace.
+var AssertPanics = true
+
+// An AssertionError describes a failed assertion.
+type AssertionError struct {
+	Msg   string
+	Stack []byte // Stack trace of the goroutine making the assertion
+}
+
+func (e *AssertionError) Error() string {
+	return "dbg: assertion failed: " + e.Msg
+}
+
+// Assert checks that cond is true, if this Debug is active. If it is
+// false, the assertion fails, with a message formatted from format and
+// args as fmt.Sprintf does. See AssertPanics.
+//
+// When built with the nodebug build flag, Assert does nothing, though
+// its arguments are still evaluated. Use Invariant for checks which
+// are expensive.
+func (d Debug) Assert(cond bool, format string, args ...interface{}) {
+	if !enabled || !d.On() || cond {
+		return
+	}
+	fail(log.Output, fmt.Sprintf(format, args...))
+}
+
+// Invariant calls check, if this Debug is active. If it returns an
+// error, the assertion fails, with the error as its message. See
+// AssertPanics.
+//
+// When built with the nodebug build flag, Invariant does nothing, and
+// check is never called.
+func (d Debug) Invariant(check func() error) {
+	if !enabled || !d {
+		return
+	}
+	if err := check(); err != nil {
+		fail(log.Output, err.Error())
+	}
+}
+
+// fail panics with an AssertionError for msg, or writes it through
+// output, as chosen by AssertPanics. It must be called directly from
+// the exported method the user called, to get the right caller for
+// log.Lshortfile.
+func fail(output outputFunc, msg string) {
+	err := &AssertionError{Msg: msg, Stack: stack()}
+
+	if AssertPanics {
+		panic(err)
+	}
+	output(3, err.Error()+"\n"+string(err.Stack))
+}
+
+// stack returns the full stack trace of the calling goroutine, growing
+// the buffer until it fits.
+func stack() []byte {
+	buf := make([]byte, 4096)
+	for {
+		n := runtime.Stack(buf, false)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
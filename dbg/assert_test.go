@@ -0,0 +1,118 @@
+package dbg
+
+import (
+	"errors"
+	"strings"
+	. "testing"
+)
+
+// recoverAssertion runs f, and returns the AssertionError it panicked
+// with, if any.
+func recoverAssertion(t *T, f func()) (err *AssertionError) {
+	defer func() {
+		if r := recover(); r != nil {
+			var ok bool
+			if err, ok = r.(*AssertionError); !ok {
+				t.Fatalf("expected *AssertionError, got %#v", r)
+			}
+		}
+	}()
+	f()
+	return nil
+}
+
+func TestAssert(t *T) {
+	d := Debug(true)
+
+	if err := recoverAssertion(t, func() { d.Assert(1 < 2, "math") }); err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+
+	err := recoverAssertion(t, func() { d.Assert(len("ab") == 3, "len %d", 2) })
+	if !enabled {
+		if err != nil {
+			t.Errorf("expected no failure under nodebug, got %v", err)
+		}
+		return
+	}
+	if err == nil {
+		t.Fatal("expected failed assertion to panic")
+	}
+	if msg := err.Error(); msg != "dbg: assertion failed: len 2" {
+		t.Errorf("unexpected message %q", msg)
+	}
+	if !strings.Contains(string(err.Stack), "TestAssert") {
+		t.Errorf("expected stack to include the test, got:\n%s", err.Stack)
+	}
+
+	if err := recoverAssertion(t, func() { Debug(false).Assert(false, "off") }); err != nil {
+		t.Errorf("expected no failure when off, got %v", err)
+	}
+}
+
+func TestInvariant(t *T) {
+	called := false
+	Debug(false).Invariant(func() error {
+		called = true
+		return nil
+	})
+	if called {
+		t.Error("expected check not to be called when off")
+	}
+
+	err := recoverAssertion(t, func() {
+		Debug(true).Invariant(func() error { return errors.New("broken") })
+	})
+	if enabled && (err == nil || err.Msg != "broken") {
+		t.Errorf("expected failure with message \"broken\", got %v", err)
+	}
+}
+
+func TestAssertLogs(t *T) {
+	if !enabled {
+		t.Skip("built with nodebug")
+	}
+
+	// AssertPanics is shared by the whole process, so this test must
+	// not run in parallel with others making assertions.
+	AssertPanics = false
+	defer func() { AssertPanics = true }()
+
+	rec := new(Recorder)
+	s := Debug(true).To(rec)
+	s.Assert(false, "logged")
+	s.Invariant(func() error { return errors.New("broken") })
+
+	msgs := rec.Messages()
+	if len(msgs) != 2 {
+		t.Fatalf("expected 2 messages, got %q", msgs)
+	}
+	if !strings.HasPrefix(msgs[0], "dbg: assertion failed: logged\ngoroutine ") {
+		t.Errorf("expected message and stack trace, got %q", msgs[0])
+	}
+	if !strings.HasPrefix(msgs[1], "dbg: assertion failed: broken\ngoroutine ") {
+		t.Errorf("expected invariant message and stack trace, got %q", msgs[1])
+	}
+}
+
+func TestAssertDeepStack(t *T) {
+	if !enabled {
+		t.Skip("built with nodebug")
+	}
+
+	var recurse func(n int) *AssertionError
+	recurse = func(n int) *AssertionError {
+		if n == 0 {
+			return recoverAssertion(t, func() { Debug(true).Assert(false, "deep") })
+		}
+		return recurse(n - 1)
+	}
+
+	err := recurse(200)
+	if err == nil {
+		t.Fatal("expected failed assertion to panic")
+	}
+	if len(err.Stack) <= 4096 || !strings.Contains(string(err.Stack), "TestAssertDeepStack") {
+		t.Errorf("expected the whole stack, down to the test, got %d bytes", len(err.Stack))
+	}
+}
@@ -0,0 +1,176 @@
+package dbg
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// EnvVar is the environment variable read at startup to choose which
+// named Channels are on. See Configure for its format.
+const EnvVar = "MISCDEBUG"
+
+// A Channel is a named debugging switch, usually one per package or
+// subsystem, which prints messages like a Debug value while it is on.
+// Messages are prefixed with the Channel's name, and any decorations
+// chosen with SetFlags and SetSince.
+//
+// Channels are off until turned on by name, through the MISCDEBUG
+// environment variable, Configure, or Set. When built with the nodebug
+// build flag, Channels are always off.
+type Channel struct {
+	name  string
+	on    int32
+	flags int32
+
+	lock  sync.Mutex
+	since string
+}
+
+var registry = struct {
+	sync.Mutex
+	spec     []channelRule
+	channels map[string]*Channel
+}{channels: map[string]*Channel{}}
+
+type channelRule struct {
+	pattern string
+	on      bool
+}
+
+func init() {
+	if err := Configure(os.Getenv(EnvVar)); err != nil {
+		log.Printf("dbg: bad %s: %v", EnvVar, err)
+	}
+}
+
+// New returns the Channel with the given name, registering it if this
+// is the first call for that name. A new Channel is on if the current
+// configuration turns it on.
+func New(name string) *Channel {
+	registry.Lock()
+	defer registry.Unlock()
+
+	if c, ok := registry.channels[name]; ok {
+		return c
+	}
+	c := &Channel{name: name}
+	c.Set(matchRules(registry.spec, name))
+	registry.channels[name] = c
+	return c
+}
+
+// Configure sets which Channels are on, for both registered Channels
+// and those created later. The spec is a comma separated list of
+// patterns, as understood by path.Match, and each Channel whose name
+// matches a pattern is turned on. A pattern starting with '-' turns
+// matching Channels off instead. Later patterns override earlier ones,
+// eg: "chans.*,-chans.router,pp".
+//
+// If a pattern is malformed, nothing is changed and an error is
+// returned.
+func Configure(spec string) error {
+	var rules []channelRule
+	for _, field := range strings.Split(spec, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		rule := channelRule{pattern: field, on: true}
+		if strings.HasPrefix(field, "-") {
+			rule = channelRule{pattern: field[1:], on: false}
+		}
+		if _, err := path.Match(rule.pattern, ""); err != nil {
+			return fmt.Errorf("pattern %q: %v", field, err)
+		}
+		rules = append(rules, rule)
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+
+	registry.spec = rules
+	for name, c := range registry.channels {
+		c.Set(matchRules(rules, name))
+	}
+	return nil
+}
+
+// matchRules reports whether the last rule matching name turns it on.
+func matchRules(rules []channelRule, name string) bool {
+	on := false
+	for _, rule := range rules {
+		if ok, _ := path.Match(rule.pattern, name); ok {
+			on = rule.on
+		}
+	}
+	return on
+}
+
+// Channels returns the names of all registered Channels, in sorted
+// order.
+func Channels() []string {
+	registry.Lock()
+	defer registry.Unlock()
+
+	names := make([]string, 0, len(registry.channels))
+	for name := range registry.channels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Name returns the name the Channel was created with.
+func (c *Channel) Name() string {
+	return c.name
+}
+
+// On reports whether the Channel is on.
+func (c *Channel) On() bool {
+	return enabled && atomic.LoadInt32(&c.on) != 0
+}
+
+// Set turns the Channel on or off, until the next call to Configure.
+func (c *Channel) Set(on bool) {
+	var v int32
+	if on {
+		v = 1
+	}
+	atomic.StoreInt32(&c.on, v)
+}
+
+// Println calls log.Println on the arguments, prefixed with the
+// Channel's name, if this Channel is on.
+func (c *Channel) Println(v ...interface{}) {
+	if c.On() {
+		c.output(fmt.Sprintln(v...))
+	}
+}
+
+// Printf calls log.Printf on the arguments, prefixed with the
+// Channel's name, if this Channel is on.
+func (c *Channel) Printf(format string, v ...interface{}) {
+	if c.On() {
+		c.output(fmt.Sprintf(format, v...))
+	}
+}
+
+// Print calls log.Print on the arguments, prefixed with the Channel's
+// name, if this Channel is on.
+func (c *Channel) Print(v ...interface{}) {
+	if c.On() {
+		c.output(fmt.Sprint(v...))
+	}
+}
+
+// output must be called directly from the exported method the user
+// called, to get the right caller for log.Lshortfile.
+func (c *Channel) output(s string) {
+	log.Output(3, c.name+": "+decorate(c.Flags(), c.Since())+s)
+}
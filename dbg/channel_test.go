@@ -0,0 +1,91 @@
+package dbg
+
+import (
+	"reflect"
+	"strings"
+	. "testing"
+)
+
+var configureTests = []struct {
+	Spec string
+	On   map[string]bool
+}{
+	{"", map[string]bool{"test.a": false, "test.a.b": false, "test.pp": false}},
+	{"test.*", map[string]bool{"test.a": true, "test.a.b": true, "test.pp": true}},
+	{"test.*,-test.a.*", map[string]bool{"test.a": true, "test.a.b": false, "test.pp": true}},
+	{" test.pp , test.a", map[string]bool{"test.a": true, "test.a.b": false, "test.pp": true}},
+	{"-test.*,test.a.b", map[string]bool{"test.a": false, "test.a.b": true, "test.pp": false}},
+}
+
+func TestConfigure(t *T) {
+	if !enabled {
+		t.Skip("built with nodebug")
+	}
+	defer Configure("")
+
+	for _, test := range configureTests {
+		if err := Configure(test.Spec); err != nil {
+			t.Fatalf("Configure(%q): %v", test.Spec, err)
+		}
+		for name, expect := range test.On {
+			if on := New(name).On(); on != expect {
+				t.Errorf("Configure(%q): %s: expected %v, got %v", test.Spec, name, expect, on)
+			}
+		}
+	}
+
+	// Channels created after Configure pick up the spec.
+	Configure("test.late")
+	if !New("test.late").On() {
+		t.Error("expected new channel to follow the current spec")
+	}
+
+	if err := Configure("test.[,pp"); err == nil {
+		t.Error("expected error from bad pattern")
+	}
+	if !New("test.late").On() {
+		t.Error("expected bad spec to leave channels unchanged")
+	}
+}
+
+func TestChannel(t *T) {
+	if !enabled {
+		t.Skip("built with nodebug")
+	}
+
+	buf := setupLogger()
+	defer resetLogger()
+
+	c := New("test.print")
+	if New("test.print") != c {
+		t.Error("expected New to return the registered channel")
+	}
+
+	c.Print("hidden")
+	if buf.Len() != 0 {
+		t.Errorf("expected no output when off, got %q", buf.String())
+	}
+
+	c.Set(true)
+	defer c.Set(false)
+	c.Println("a", 1)
+	c.Printf("%d", 2)
+	if out, expect := buf.String(), "test.print: a 1\ntest.print: 2\n"; out != expect {
+		t.Errorf("expected %q, got %q", expect, out)
+	}
+}
+
+func TestChannels(t *T) {
+	New("test.list.b")
+	New("test.list.a")
+
+	var names []string
+	for _, name := range Channels() {
+		if strings.HasPrefix(name, "test.list.") {
+			names = append(names, name)
+		}
+	}
+	if expect := []string{"test.list.a", "test.list.b"}; !reflect.DeepEqual(names, expect) {
+		t.Errorf("expected %q, got %q", expect, names)
+	}
+}
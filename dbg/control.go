@@ -0,0 +1,97 @@
+package dbg
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+)
+
+// SetMatching turns every registered Channel whose name matches
+// pattern, as understood by path.Match, on or off, until the next call
+// to Configure. It returns the number of Channels matched.
+func SetMatching(pattern string, on bool) (int, error) {
+	if err := checkPattern(pattern); err != nil {
+		return 0, err
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+
+	n := 0
+	for name, c := range registry.channels {
+		if ok, _ := path.Match(pattern, name); ok {
+			c.Set(on)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// checkPattern returns an error if pattern is malformed.
+func checkPattern(pattern string) error {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("pattern %q: %v", pattern, err)
+	}
+	return nil
+}
+
+// ControlHandler returns an http.Handler for switching Channels on and
+// off at runtime. It is not registered anywhere by default; a program
+// opts in by adding it to its own ServeMux, eg:
+//
+//	http.Handle("/debug/dbg", dbg.ControlHandler())
+//
+// A GET request lists each registered Channel and its state, one per
+// line, as text:
+//
+//	chans.publisher on
+//	pp off
+//
+// A POST request first turns on the Channels matching each "on" form
+// value, and then turns off those matching each "off" form value, before
+// replying with the list. The values are patterns as for SetMatching;
+// if any of them is malformed, no Channel is changed.
+func ControlHandler() http.Handler {
+	return http.HandlerFunc(serveControl)
+}
+
+func serveControl(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET", "HEAD":
+	case "POST":
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		changes := []struct {
+			key string
+			on  bool
+		}{{"on", true}, {"off", false}}
+		for _, change := range changes {
+			for _, pattern := range r.PostForm[change.key] {
+				if err := checkPattern(pattern); err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+			}
+		}
+		for _, change := range changes {
+			for _, pattern := range r.PostForm[change.key] {
+				SetMatching(pattern, change.on)
+			}
+		}
+	default:
+		w.Header().Set("Allow", "GET, HEAD, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	for _, name := range Channels() {
+		state := "off"
+		if New(name).On() {
+			state = "on"
+		}
+		fmt.Fprintln(w, name, state)
+	}
+}
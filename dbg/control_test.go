@@ -0,0 +1,91 @@
+package dbg
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	. "testing"
+)
+
+// controlList returns the lines of a ControlHandler listing for the
+// Channels made by these tests.
+func controlList(body string) string {
+	var lines []string
+	for _, line := range strings.Split(body, "\n") {
+		if strings.HasPrefix(line, "test.ctl.") {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestControlHandler(t *T) {
+	if !enabled {
+		t.Skip("built with nodebug")
+	}
+
+	a, b := New("test.ctl.a"), New("test.ctl.b")
+	defer a.Set(false)
+	defer b.Set(false)
+
+	srv := httptest.NewServer(ControlHandler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out, expect := controlList(string(body)), "test.ctl.a off\ntest.ctl.b off"; out != expect {
+		t.Errorf("GET: expected %q, got %q", expect, out)
+	}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/", strings.NewReader(url.Values{
+		"on":  {"test.ctl.*"},
+		"off": {"test.ctl.b"},
+	}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	ControlHandler().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST: expected status 200, got %d", rec.Code)
+	}
+	if out, expect := controlList(rec.Body.String()), "test.ctl.a on\ntest.ctl.b off"; out != expect {
+		t.Errorf("POST: expected %q, got %q", expect, out)
+	}
+	if !a.On() || b.On() {
+		t.Errorf("expected a on and b off, got %v and %v", a.On(), b.On())
+	}
+}
+
+func TestControlHandlerErrors(t *T) {
+	c := New("test.ctlerr")
+	c.Set(false)
+
+	for _, test := range []struct {
+		method, body string
+		code         int
+	}{
+		{"DELETE", "", http.StatusMethodNotAllowed},
+		{"POST", "on=test.[", http.StatusBadRequest},
+		{"POST", "on=test.ctlerr&on=test.[", http.StatusBadRequest},
+	} {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(test.method, "/", strings.NewReader(test.body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		ControlHandler().ServeHTTP(rec, req)
+		if rec.Code != test.code {
+			t.Errorf("%s %q: expected status %d, got %d", test.method, test.body, test.code, rec.Code)
+		}
+		if c.On() {
+			t.Errorf("%s %q: expected no Channel changed", test.method, test.body)
+		}
+	}
+}
@@ -4,3 +4,6 @@ package dbg
 
 // A default Debug value controlled by the nodebug build flag
 const Default = Debug(false)
+
+// enabled reports that debugging code is compiled away.
+const enabled = false
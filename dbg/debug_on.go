@@ -4,3 +4,6 @@ package dbg
 
 // A default Debug value controlled by the nodebug build flag.
 var Default = Debug(true)
+
+// enabled reports that debugging code is built in.
+const enabled = true
@@ -0,0 +1,185 @@
+package dbg
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Flags choose the decorations added to the start of each message by
+// a Decorated value or a Channel. They are independent of the flags of
+// the log package.
+type Flags int32
+
+const (
+	// FlagCaller adds the file name and line number of the call into
+	// dbg, eg: "publish.go:42".
+	FlagCaller Flags = 1 << iota
+	// FlagElapsed adds the time since the process started, or since
+	// a named Checkpoint, eg: "+1.5ms".
+	FlagElapsed
+	// FlagGoroutine adds the ID of the calling goroutine, eg: "g7".
+	FlagGoroutine
+)
+
+var (
+	start = time.Now()
+
+	checkpoints = struct {
+		sync.Mutex
+		times map[string]time.Time
+	}{times: map[string]time.Time{}}
+
+	// dbgDir is the directory holding this package's source, whose
+	// frames are skipped when looking for the caller.
+	dbgDir = func() string {
+		_, file, _, _ := runtime.Caller(0)
+		return filepath.Dir(file)
+	}()
+)
+
+// Checkpoint records the current time under name, so that messages
+// from Decorated values with Since set to name, and Channels with
+// SetSince(name), show the time elapsed from now. Calling it again
+// moves the checkpoint.
+func Checkpoint(name string) {
+	checkpoints.Lock()
+	defer checkpoints.Unlock()
+	checkpoints.times[name] = time.Now()
+}
+
+// since returns the time of the named checkpoint, or the process start
+// if there is no such checkpoint.
+func since(name string) time.Time {
+	checkpoints.Lock()
+	defer checkpoints.Unlock()
+	if t, ok := checkpoints.times[name]; ok {
+		return t
+	}
+	return start
+}
+
+// A Decorated is like a Debug value, but prefixes each message with the
+// information chosen by Flags, followed by ": ".
+type Decorated struct {
+	Debug Debug // Whether messages are written
+	Flags Flags
+	Since string // Checkpoint measured from by FlagElapsed; "" is the process start
+}
+
+// On reports whether this Decorated is active.
+func (d Decorated) On() bool {
+	return d.Debug.On()
+}
+
+// Println calls log.Println on the arguments, with decorations, if this
+// Decorated is active.
+func (d Decorated) Println(v ...interface{}) {
+	if d.Debug {
+		log.Output(2, decorate(d.Flags, d.Since)+fmt.Sprintln(v...))
+	}
+}
+
+// Printf calls log.Printf on the arguments, with decorations, if this
+// Decorated is active.
+func (d Decorated) Printf(format string, v ...interface{}) {
+	if d.Debug {
+		log.Output(2, decorate(d.Flags, d.Since)+fmt.Sprintf(format, v...))
+	}
+}
+
+// Print calls log.Print on the arguments, with decorations, if this
+// Decorated is active.
+func (d Decorated) Print(v ...interface{}) {
+	if d.Debug {
+		log.Output(2, decorate(d.Flags, d.Since)+fmt.Sprint(v...))
+	}
+}
+
+// Flags returns the decorations added to the Channel's messages.
+func (c *Channel) Flags() Flags {
+	return Flags(atomic.LoadInt32(&c.flags))
+}
+
+// SetFlags sets the decorations added to the Channel's messages.
+func (c *Channel) SetFlags(flags Flags) {
+	atomic.StoreInt32(&c.flags, int32(flags))
+}
+
+// Since returns the name of the Checkpoint FlagElapsed measures from
+// for the Channel's messages, or "" for the process start.
+func (c *Channel) Since() string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.since
+}
+
+// SetSince sets the name of the Checkpoint FlagElapsed measures from
+// for the Channel's messages. The empty name, which is the default,
+// measures from the process start.
+func (c *Channel) SetSince(checkpoint string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.since = checkpoint
+}
+
+// decorate returns the prefix for a message chosen by flags, or "" if
+// flags is zero.
+func decorate(flags Flags, checkpoint string) string {
+	if flags == 0 {
+		return ""
+	}
+
+	var parts []string
+	if flags&FlagCaller != 0 {
+		parts = append(parts, caller())
+	}
+	if flags&FlagElapsed != 0 {
+		parts = append(parts, "+"+time.Since(since(checkpoint)).String())
+	}
+	if flags&FlagGoroutine != 0 {
+		parts = append(parts, "g"+strconv.FormatUint(goroutineID(), 10))
+	}
+	return strings.Join(parts, " ") + ": "
+}
+
+// caller returns the short file name and line of the first frame on the
+// stack outside of this package, not counting its tests.
+func caller() string {
+	file, line := callerFrame()
+	return filepath.Base(file) + ":" + strconv.Itoa(line)
+}
+
+// callerFrame returns the file and line of the first frame on the stack
+// outside of this package, not counting its tests.
+func callerFrame() (string, int) {
+	for skip := 2; ; skip++ {
+		_, file, line, ok := runtime.Caller(skip)
+		if !ok {
+			return "???", 0
+		}
+		if filepath.Dir(file) != dbgDir || strings.HasSuffix(file, "_test.go") {
+			return file, line
+		}
+	}
+}
+
+// goroutineID returns the ID of the calling goroutine, as shown in the
+// header of its stack trace, or 0 if it cannot be found.
+func goroutineID() uint64 {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	buf = bytes.TrimPrefix(buf, []byte("goroutine "))
+	if i := bytes.IndexByte(buf, ' '); i >= 0 {
+		buf = buf[:i]
+	}
+	id, _ := strconv.ParseUint(string(buf), 10, 64)
+	return id
+}
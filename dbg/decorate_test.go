@@ -0,0 +1,92 @@
+package dbg
+
+import (
+	"regexp"
+	"runtime"
+	"strconv"
+	. "testing"
+)
+
+var decoratedTests = []struct {
+	Flags  Flags
+	Expect string
+}{
+	{0, `^msg\n$`},
+	{FlagCaller, `^decorate_test\.go:\d+: msg\n$`},
+	{FlagElapsed, `^\+[0-9.]+[nµm]?s: msg\n$`},
+	{FlagGoroutine, `^g[1-9][0-9]*: msg\n$`},
+	{FlagCaller | FlagElapsed | FlagGoroutine, `^decorate_test\.go:\d+ \+\S+ g\d+: msg\n$`},
+}
+
+func TestDecorated(t *T) {
+	buf := setupLogger()
+	defer resetLogger()
+
+	for _, test := range decoratedTests {
+		buf.Reset()
+		Decorated{Debug: true, Flags: test.Flags}.Println("msg")
+		if out := buf.String(); !regexp.MustCompile(test.Expect).MatchString(out) {
+			t.Errorf("Flags %b: expected match for %q, got %q", test.Flags, test.Expect, out)
+		}
+	}
+
+	buf.Reset()
+	Decorated{Flags: FlagCaller}.Print("hidden")
+	if buf.Len() != 0 {
+		t.Errorf("expected no output when off, got %q", buf.String())
+	}
+}
+
+func TestDecoratedCaller(t *T) {
+	buf := setupLogger()
+	defer resetLogger()
+
+	d := Decorated{Debug: true, Flags: FlagCaller}
+	_, _, line, _ := runtime.Caller(0)
+	d.Printf("x")
+	if out, expect := buf.String(), "decorate_test.go:"+strconv.Itoa(line+1)+": x\n"; out != expect {
+		t.Errorf("expected %q, got %q", expect, out)
+	}
+
+	if !enabled {
+		return
+	}
+	buf.Reset()
+	c := New("test.decorate")
+	c.Set(true)
+	defer c.Set(false)
+	c.SetFlags(FlagCaller)
+	_, _, line, _ = runtime.Caller(0)
+	c.Print("y")
+	if out, expect := buf.String(), "test.decorate: decorate_test.go:"+strconv.Itoa(line+1)+": y\n"; out != expect {
+		t.Errorf("expected %q, got %q", expect, out)
+	}
+}
+
+func TestCheckpoint(t *T) {
+	buf := setupLogger()
+	defer resetLogger()
+
+	Checkpoint("test")
+	Decorated{Debug: true, Flags: FlagElapsed, Since: "test"}.Print("x")
+	// Only a moment has passed since the checkpoint, unlike the start
+	// of the process.
+	if out := buf.String(); !regexp.MustCompile(`^\+[0-9.]+[nµ]s: x\n$`).MatchString(out) {
+		t.Errorf("expected a short elapsed time, got %q", out)
+	}
+
+	if !enabled {
+		return
+	}
+	buf.Reset()
+	c := New("test.since")
+	c.Set(true)
+	defer c.Set(false)
+	c.SetFlags(FlagElapsed)
+	c.SetSince("test")
+	Checkpoint("test")
+	c.Print("y")
+	if out := buf.String(); !regexp.MustCompile(`^test\.since: \+[0-9.]+[nµ]s: y\n$`).MatchString(out) {
+		t.Errorf("expected a short elapsed time for Channel, got %q", out)
+	}
+}
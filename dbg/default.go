@@ -1,5 +1,7 @@
 package dbg
 
+import "time"
+
 // Println is equivalent to Default.Println
 func Println(v ...interface{}) {
 	Default.Println(v...)
@@ -14,3 +16,33 @@ func Printf(format string, v ...interface{}) {
 func Print(v ...interface{}) {
 	Default.Print(v...)
 }
+
+// Span is equivalent to Default.Span
+func Span(name string) func() {
+	return Default.Span(name)
+}
+
+// Dump is equivalent to Default.Dump
+func Dump(values ...interface{}) {
+	Default.Dump(values...)
+}
+
+// Every is equivalent to Default.Every
+func Every(n int) Debug {
+	return Default.Every(n)
+}
+
+// Limit is equivalent to Default.Limit
+func Limit(n int, interval time.Duration) Debug {
+	return Default.Limit(n, interval)
+}
+
+// Assert is equivalent to Default.Assert
+func Assert(cond bool, format string, args ...interface{}) {
+	Default.Assert(cond, format, args...)
+}
+
+// Invariant is equivalent to Default.Invariant
+func Invariant(check func() error) {
+	Default.Invariant(check)
+}
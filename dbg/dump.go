@@ -0,0 +1,128 @@
+package dbg
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"log"
+	"sync"
+
+	"github.com/cookieo9/go-misc/pp"
+)
+
+// sources caches the parsed source files of Dump's callers.
+var sources = struct {
+	sync.Mutex
+	files map[string]*sourceFile
+}{files: map[string]*sourceFile{}}
+
+type sourceFile struct {
+	src  []byte
+	fset *token.FileSet
+	ast  *ast.File // nil if the file couldn't be read or parsed
+}
+
+// Dump logs each of the values pretty-printed by pp.PP, if this Debug
+// is active. Each value is labelled with its expression in the source of
+// the call, eg:
+//
+//	debug.Dump(cfg.Addr, len(queue))
+//
+// logs:
+//
+//	cfg.Addr = "localhost:80"
+//	len(queue) = 3
+//
+// If the source isn't available, values are labelled with "?".
+//
+// When built with the nodebug build flag, Dump does nothing.
+func (d Debug) Dump(values ...interface{}) {
+	if !enabled || !d {
+		return
+	}
+	dump(log.Output, values)
+}
+
+// dump writes the labelled values through output. It must be called
+// directly from the exported method the user called, to get the right
+// caller for log.Lshortfile.
+func dump(output outputFunc, values []interface{}) {
+	file, line := callerFrame()
+	exprs := callArgs(file, line, len(values))
+
+	buf := new(bytes.Buffer)
+	for i, v := range values {
+		if i > 0 {
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(exprs[i])
+		buf.WriteString(" = ")
+		buf.WriteString(pp.PP(v))
+	}
+	output(3, buf.String())
+}
+
+// callArgs returns the source text of the n arguments of the first call
+// to a function named Dump spanning the given line of file. If there is no
+// such call, each argument is given as "?".
+func callArgs(file string, line, n int) []string {
+	exprs := make([]string, n)
+	for i := range exprs {
+		exprs[i] = "?"
+	}
+
+	f := loadSource(file)
+	if f.ast == nil {
+		return exprs
+	}
+
+	found := false
+	ast.Inspect(f.ast, func(node ast.Node) bool {
+		call, ok := node.(*ast.CallExpr)
+		if found || !ok || len(call.Args) != n {
+			return !found
+		}
+		if line < f.fset.Position(call.Pos()).Line || line > f.fset.Position(call.End()).Line {
+			return true
+		}
+
+		var name string
+		switch fun := call.Fun.(type) {
+		case *ast.Ident:
+			name = fun.Name
+		case *ast.SelectorExpr:
+			name = fun.Sel.Name
+		}
+		if name != "Dump" {
+			return true
+		}
+
+		found = true
+		for i, arg := range call.Args {
+			start := f.fset.Position(arg.Pos()).Offset
+			end := f.fset.Position(arg.End()).Offset
+			exprs[i] = string(f.src[start:end])
+		}
+		return false
+	})
+	return exprs
+}
+
+func loadSource(file string) *sourceFile {
+	sources.Lock()
+	defer sources.Unlock()
+
+	if f, ok := sources.files[file]; ok {
+		return f
+	}
+
+	f := &sourceFile{fset: token.NewFileSet()}
+	if src, err := ioutil.ReadFile(file); err == nil {
+		f.src = src
+		f.ast, _ = parser.ParseFile(f.fset, file, src, 0)
+	}
+	sources.files[file] = f
+	return f
+}
@@ -0,0 +1,52 @@
+package dbg
+
+import (
+	"reflect"
+	. "testing"
+)
+
+type dumpPoint struct {
+	X, Y int
+}
+
+func TestDump(t *T) {
+	rec := new(Recorder)
+	d := Debug(true).To(rec)
+	p := dumpPoint{1, 2}
+	name := "bob"
+	d.Dump(p.X+1, name,
+		p)
+
+	expect := []string{"p.X+1 = 2\nname = \"bob\"\np = dbg.dumpPoint (\n    X:  1,\n    Y:  2,\n)"}
+	if !enabled {
+		expect = []string{}
+	}
+	if msgs := rec.Messages(); !reflect.DeepEqual(msgs, expect) {
+		t.Errorf("expected %q, got %q", expect, msgs)
+	}
+
+	rec.Reset()
+	Debug(false).To(rec).Dump(name)
+	if msgs := rec.Messages(); len(msgs) != 0 {
+		t.Errorf("expected no output when off, got %q", msgs)
+	}
+}
+
+func TestDumpDefault(t *T) {
+	// The package level Dump only writes to the default log.
+	buf := setupLogger()
+	defer resetLogger()
+
+	name := "bob"
+	Dump(name)
+	if out := buf.String(); enabled && out != "name = \"bob\"\n" {
+		t.Errorf("package Dump: expected name = \"bob\", got %q", out)
+	}
+}
+
+func TestCallArgs(t *T) {
+	args := callArgs("no such file", 1, 2)
+	if len(args) != 2 || args[0] != "?" || args[1] != "?" {
+		t.Errorf("expected [? ?] for missing source, got %q", args)
+	}
+}
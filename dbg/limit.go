@@ -0,0 +1,166 @@
+package dbg
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// A callSite identifies the line of code calling into dbg.
+type callSite struct {
+	file string
+	line int
+}
+
+// sites holds the state of Every and Limit for each call site.
+var sites = struct {
+	sync.Mutex
+	counts map[callSite]uint64
+	limits map[callSite]*siteLimit
+}{
+	counts: map[callSite]uint64{},
+	limits: map[callSite]*siteLimit{},
+}
+
+// resetSites forgets the state of Every and Limit for all call sites.
+func resetSites() {
+	sites.Lock()
+	defer sites.Unlock()
+	sites.counts = map[callSite]uint64{}
+	sites.limits = map[callSite]*siteLimit{}
+}
+
+type siteLimit struct {
+	start time.Time
+	count int
+}
+
+// Every returns a Debug which is active only on every nth call from
+// the same line of code, starting with the first, and only if d is
+// active itself. It is meant to be used in hot loops:
+//
+//	debug.Every(1000).Printf("at %d", i)
+//
+// When built with the nodebug build flag, Every always returns false.
+func (d Debug) Every(n int) Debug {
+	if !enabled || !d {
+		return false
+	}
+
+	site := currentSite()
+	sites.Lock()
+	defer sites.Unlock()
+
+	count := sites.counts[site]
+	sites.counts[site] = count + 1
+	return n <= 1 || count%uint64(n) == 0
+}
+
+// Limit returns a Debug which is active for at most n calls from the
+// same line of code in each interval, and only if d is active itself:
+//
+//	debug.Limit(5, time.Second).Println("dropped packet", id)
+//
+// When built with the nodebug build flag, Limit always returns false.
+func (d Debug) Limit(n int, interval time.Duration) Debug {
+	if !enabled || !d {
+		return false
+	}
+
+	site := currentSite()
+	now := time.Now()
+	sites.Lock()
+	defer sites.Unlock()
+
+	limit := sites.limits[site]
+	if limit == nil || now.Sub(limit.start) >= interval {
+		limit = &siteLimit{start: now}
+		sites.limits[site] = limit
+	}
+	if limit.count >= n {
+		return false
+	}
+	limit.count++
+	return true
+}
+
+func currentSite() callSite {
+	file, line := callerFrame()
+	return callSite{file, line}
+}
+
+// A Dedup collapses identical consecutive messages. Rather than logging
+// a message again, it counts the repeats, and logs
+//
+//	last message repeated K times
+//
+// once a different message arrives, or Flush is called. A Dedup is
+// active while its Debug is true, and the zero value is ready to use.
+type Dedup struct {
+	Debug Debug // Whether messages are written
+
+	lock    sync.Mutex
+	last    string
+	repeats int
+}
+
+// On reports whether this Dedup is active.
+func (d *Dedup) On() bool {
+	return d.Debug.On()
+}
+
+// Println formats the arguments as log.Println does, and logs the
+// result if this Dedup is active and it differs from the last message.
+func (d *Dedup) Println(v ...interface{}) {
+	if d.Debug {
+		d.output(fmt.Sprintln(v...))
+	}
+}
+
+// Printf formats the arguments as log.Printf does, and logs the result
+// if this Dedup is active and it differs from the last message.
+func (d *Dedup) Printf(format string, v ...interface{}) {
+	if d.Debug {
+		d.output(fmt.Sprintf(format, v...))
+	}
+}
+
+// Print formats the arguments as log.Print does, and logs the result
+// if this Dedup is active and it differs from the last message.
+func (d *Dedup) Print(v ...interface{}) {
+	if d.Debug {
+		d.output(fmt.Sprint(v...))
+	}
+}
+
+// Flush logs the number of times the last message was repeated, if it
+// was repeated since last being logged.
+func (d *Dedup) Flush() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.flush(3)
+}
+
+func (d *Dedup) flush(calldepth int) {
+	if d.repeats > 0 {
+		log.Output(calldepth, "last message repeated "+strconv.Itoa(d.repeats)+" times")
+		d.repeats = 0
+	}
+}
+
+// output must be called directly from the exported method the user
+// called, to get the right caller for log.Lshortfile.
+func (d *Dedup) output(s string) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if s == d.last {
+		d.repeats++
+		return
+	}
+	d.flush(4)
+	d.last = s
+	log.Output(3, s)
+}
@@ -0,0 +1,84 @@
+package dbg
+
+import (
+	"reflect"
+	. "testing"
+	"time"
+)
+
+func TestEvery(t *T) {
+	resetSites()
+
+	rec := new(Recorder)
+	d := Debug(true).To(rec)
+	for i := 0; i < 10; i++ {
+		d.Every(4).Print(i)
+		d.Every(5).Print(i)
+	}
+
+	expect := []string{"0", "0", "4", "5", "8"}
+	if !enabled {
+		expect = []string{}
+	}
+	if msgs := rec.Messages(); !reflect.DeepEqual(msgs, expect) {
+		t.Errorf("expected %q, got %q", expect, msgs)
+	}
+
+	if Debug(false).Every(1) {
+		t.Error("expected Every to be off when Debug is off")
+	}
+}
+
+func TestLimit(t *T) {
+	if !enabled {
+		t.Skip("built with nodebug")
+	}
+	resetSites()
+
+	d := Debug(true)
+	count := func() int {
+		n := 0
+		for i := 0; i < 5; i++ {
+			if d.Limit(2, 50*time.Millisecond) {
+				n++
+			}
+		}
+		return n
+	}
+
+	if n := count(); n != 2 {
+		t.Errorf("expected 2 messages in first interval, got %d", n)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if n := count(); n != 2 {
+		t.Errorf("expected 2 messages in next interval, got %d", n)
+	}
+
+	if Debug(false).Limit(1, time.Second) {
+		t.Error("expected Limit to be off when Debug is off")
+	}
+}
+
+func TestDedup(t *T) {
+	buf := setupLogger()
+	defer resetLogger()
+
+	d := &Dedup{Debug: true}
+	for _, msg := range []string{"a", "a", "a", "b", "c", "c"} {
+		d.Println(msg)
+	}
+	d.Flush()
+	d.Flush()
+
+	expect := "a\nlast message repeated 2 times\nb\nc\nlast message repeated 1 times\n"
+	if out := buf.String(); out != expect {
+		t.Errorf("expected %q, got %q", expect, out)
+	}
+
+	buf.Reset()
+	d.Debug = false
+	d.Print("hidden")
+	if buf.Len() != 0 {
+		t.Errorf("expected no output when off, got %q", buf.String())
+	}
+}
@@ -0,0 +1,176 @@
+package dbg
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+)
+
+// A Level is the importance of a message written by a Logger.
+type Level int32
+
+// The levels understood by a Logger, from least to most important.
+const (
+	LevelTrace Level = iota
+	LevelDebug
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var levelNames = []string{
+	LevelTrace: "TRACE",
+	LevelDebug: "DEBUG",
+	LevelInfo:  "INFO",
+	LevelWarn:  "WARN",
+	LevelError: "ERROR",
+}
+
+func (l Level) String() string {
+	if l < LevelTrace || l > LevelError {
+		return fmt.Sprintf("Level(%d)", int32(l))
+	}
+	return levelNames[l]
+}
+
+// A Logger writes leveled messages with key/value fields to the
+// default log. Messages below the Logger's level are skipped, and the
+// level can be changed at any time, from any goroutine.
+//
+// When built with the nodebug build flag, a Logger writes nothing,
+// and calls to its methods are compiled away, like those of Default.
+//
+// The zero value is ready to use, and writes messages at LevelInfo
+// and above.
+//
+// Messages are written as the level, the message, and then each field
+// as key=value, eg:
+//
+//	INFO request done path=/index.html status=200
+type Logger struct {
+	root   *Logger // Logger holding the level, if not this one
+	level  int32   // Accessed atomically, relative to LevelInfo
+	fields []interface{}
+}
+
+// NewLogger creates a Logger which writes messages at level or above.
+func NewLogger(level Level) *Logger {
+	l := new(Logger)
+	l.SetLevel(level)
+	return l
+}
+
+// levels returns the Logger holding l's level, which is shared by all
+// the Loggers derived from it with With.
+func (l *Logger) levels() *Logger {
+	if l.root != nil {
+		return l.root
+	}
+	return l
+}
+
+// Level returns the lowest level of message the Logger writes.
+func (l *Logger) Level() Level {
+	return LevelInfo + Level(atomic.LoadInt32(&l.levels().level))
+}
+
+// SetLevel changes the lowest level of message the Logger writes.
+// It also affects all Loggers derived from it with With.
+func (l *Logger) SetLevel(level Level) {
+	atomic.StoreInt32(&l.levels().level, int32(level-LevelInfo))
+}
+
+// Enabled reports whether the Logger writes messages at level.
+// It can be used to skip the work of preparing expensive fields.
+func (l *Logger) Enabled(level Level) bool {
+	return enabled && level >= l.Level()
+}
+
+// With returns a Logger which adds the given key/value fields to each
+// message, before the fields given to the message itself. It shares
+// its level with l.
+func (l *Logger) With(kv ...interface{}) *Logger {
+	fields := make([]interface{}, 0, len(l.fields)+len(kv))
+	fields = append(append(fields, l.fields...), kv...)
+	return &Logger{root: l.levels(), fields: fields}
+}
+
+// Log writes msg at the given level, followed by the key/value fields
+// given as alternating keys and values in kv.
+func (l *Logger) Log(level Level, msg string, kv ...interface{}) {
+	if l.Enabled(level) {
+		l.output(level, msg, kv)
+	}
+}
+
+// Trace writes msg and the fields in kv at LevelTrace.
+func (l *Logger) Trace(msg string, kv ...interface{}) {
+	if l.Enabled(LevelTrace) {
+		l.output(LevelTrace, msg, kv)
+	}
+}
+
+// Debug writes msg and the fields in kv at LevelDebug.
+func (l *Logger) Debug(msg string, kv ...interface{}) {
+	if l.Enabled(LevelDebug) {
+		l.output(LevelDebug, msg, kv)
+	}
+}
+
+// Info writes msg and the fields in kv at LevelInfo.
+func (l *Logger) Info(msg string, kv ...interface{}) {
+	if l.Enabled(LevelInfo) {
+		l.output(LevelInfo, msg, kv)
+	}
+}
+
+// Warn writes msg and the fields in kv at LevelWarn.
+func (l *Logger) Warn(msg string, kv ...interface{}) {
+	if l.Enabled(LevelWarn) {
+		l.output(LevelWarn, msg, kv)
+	}
+}
+
+// Error writes msg and the fields in kv at LevelError.
+func (l *Logger) Error(msg string, kv ...interface{}) {
+	if l.Enabled(LevelError) {
+		l.output(LevelError, msg, kv)
+	}
+}
+
+// output formats and writes a message. It must be called directly
+// from the exported method the user called, to get the right caller
+// for log.Lshortfile.
+func (l *Logger) output(level Level, msg string, kv []interface{}) {
+	buf := new(bytes.Buffer)
+	buf.WriteString(level.String())
+	buf.WriteByte(' ')
+	buf.WriteString(msg)
+	writeFields(buf, l.fields)
+	writeFields(buf, kv)
+	log.Output(3, buf.String())
+}
+
+// writeFields writes alternating keys and values from kv as key=value
+// pairs. A value without a key is written with the key !BADKEY.
+func writeFields(buf *bytes.Buffer, kv []interface{}) {
+	for i := 0; i < len(kv); i += 2 {
+		key, value := interface{}("!BADKEY"), kv[i]
+		if i+1 < len(kv) {
+			key, value = kv[i], kv[i+1]
+		}
+		fmt.Fprintf(buf, " %v=%s", key, formatValue(value))
+	}
+}
+
+// formatValue formats a field value, quoting it if it would be
+// ambiguous otherwise.
+func formatValue(value interface{}) string {
+	s := fmt.Sprint(value)
+	if s == "" || strings.ContainsAny(s, " =\"\t\n") {
+		return fmt.Sprintf("%q", s)
+	}
+	return s
+}
@@ -0,0 +1,114 @@
+package dbg
+
+import (
+	"strings"
+	. "testing"
+)
+
+var loggerTests = []struct {
+	Level  Level
+	Msg    string
+	KV     []interface{}
+	Expect string
+}{
+	{LevelTrace, "tick", nil, ""},
+	{LevelDebug, "start", []interface{}{"n", 3}, ""},
+	{LevelInfo, "done", []interface{}{"path", "/x", "ok", true}, "INFO done path=/x ok=true"},
+	{LevelWarn, "slow", []interface{}{"msg", "took ages", "empty", ""}, `WARN slow msg="took ages" empty=""`},
+	{LevelError, "odd", []interface{}{"a", 1, "dangling"}, "ERROR odd a=1 !BADKEY=dangling"},
+}
+
+func TestLogger(t *T) {
+	if !enabled {
+		t.Skip("built with nodebug")
+	}
+
+	buf := setupLogger()
+	defer resetLogger()
+
+	l := NewLogger(LevelInfo)
+	for _, test := range loggerTests {
+		buf.Reset()
+		l.Log(test.Level, test.Msg, test.KV...)
+		if out := strings.TrimSuffix(buf.String(), "\n"); out != test.Expect {
+			t.Errorf("Log(%v, %q, %v): expected %q, got %q", test.Level, test.Msg, test.KV, test.Expect, out)
+		}
+	}
+}
+
+func TestLoggerLevels(t *T) {
+	if !enabled {
+		t.Skip("built with nodebug")
+	}
+
+	buf := setupLogger()
+	defer resetLogger()
+
+	l := NewLogger(LevelWarn)
+	child := l.With("req", 7)
+
+	child.Info("hidden")
+	if buf.Len() != 0 {
+		t.Errorf("expected no output below level, got %q", buf.String())
+	}
+
+	l.SetLevel(LevelTrace)
+	child.Trace("shown")
+	if out, expect := buf.String(), "TRACE shown req=7\n"; out != expect {
+		t.Errorf("expected %q after SetLevel on parent, got %q", expect, out)
+	}
+
+	buf.Reset()
+	l.Debug("parent")
+	child.With("user", "bob").Error("failed", "code", 500)
+	if out, expect := buf.String(), "DEBUG parent\nERROR failed req=7 user=bob code=500\n"; out != expect {
+		t.Errorf("expected %q, got %q", expect, out)
+	}
+}
+
+func TestLevelString(t *T) {
+	for level, expect := range map[Level]string{
+		LevelTrace: "TRACE",
+		LevelError: "ERROR",
+		Level(9):   "Level(9)",
+	} {
+		if s := level.String(); s != expect {
+			t.Errorf("Level(%d).String(): expected %q, got %q", int32(level), expect, s)
+		}
+	}
+}
+
+func TestLoggerZero(t *T) {
+	if !enabled {
+		t.Skip("built with nodebug")
+	}
+
+	buf := setupLogger()
+	defer resetLogger()
+
+	var l Logger
+	l.Debug("hidden")
+	l.Info("shown")
+	if out, expect := buf.String(), "INFO shown\n"; out != expect {
+		t.Errorf("expected %q, got %q", expect, out)
+	}
+
+	child := l.With("a", 1)
+	l.SetLevel(LevelError)
+	child.Warn("hidden")
+	if out, expect := buf.String(), "INFO shown\n"; out != expect {
+		t.Errorf("expected child to share level, got %q", out)
+	}
+
+	// The zero value is safe to share between goroutines.
+	var shared Logger
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		shared.SetLevel(LevelWarn)
+		shared.With("b", 2).Info("hidden")
+	}()
+	shared.Enabled(LevelInfo)
+	shared.With("c", 3).Debug("hidden")
+	<-done
+}
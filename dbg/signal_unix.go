@@ -0,0 +1,43 @@
+//go:build !windows && !plan9 && !js && !wasip1
+// +build !windows,!plan9,!js,!wasip1
+
+package dbg
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// HandleSignals starts switching the Channels matching pattern, as for
+// SetMatching, on when the process receives SIGUSR1, and off when it
+// receives SIGUSR2. It returns a function which stops handling the
+// signals. It is only available on systems with these signals.
+func HandleSignals(pattern string) (stop func(), err error) {
+	if err := checkPattern(pattern); err != nil {
+		return nil, err
+	}
+
+	sigs := make(chan os.Signal, 1)
+	quit := make(chan struct{})
+	done := make(chan struct{})
+	signal.Notify(sigs, syscall.SIGUSR1, syscall.SIGUSR2)
+
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case sig := <-sigs:
+				SetMatching(pattern, sig == syscall.SIGUSR1)
+			case <-quit:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(sigs)
+		close(quit)
+		<-done
+	}, nil
+}
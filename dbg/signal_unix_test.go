@@ -0,0 +1,45 @@
+//go:build !windows && !plan9 && !js && !wasip1
+// +build !windows,!plan9,!js,!wasip1
+
+package dbg
+
+import (
+	"os"
+	"syscall"
+	. "testing"
+	"time"
+)
+
+func waitOn(t *T, c *Channel, on bool) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); c.On() != on; {
+		if time.Now().After(deadline) {
+			t.Fatalf("%s: expected On() == %v", c.Name(), on)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestHandleSignals(t *T) {
+	if !enabled {
+		t.Skip("built with nodebug")
+	}
+
+	c := New("test.sig")
+	defer c.Set(false)
+
+	if _, err := HandleSignals("test.["); err == nil {
+		t.Error("expected error from bad pattern")
+	}
+
+	stop, err := HandleSignals("test.sig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+
+	syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+	waitOn(t, c, true)
+	syscall.Kill(os.Getpid(), syscall.SIGUSR2)
+	waitOn(t, c, false)
+}
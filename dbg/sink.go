@@ -0,0 +1,166 @@
+package dbg
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A Sink is like a Debug value, but writes to its own log.Logger,
+// instead of the default log. All of its methods, including Span, Dump
+// and a failed Assert, write only to the Logger.
+//
+// Some state is still shared by the whole process: Every and Limit
+// count calls per line of code, across all Sinks and Debug values, and
+// AssertPanics chooses whether a failed Assert panics.
+type Sink struct {
+	Debug  Debug       // Whether messages are written
+	Logger *log.Logger // If nil, the default log is used.
+}
+
+// To returns a Sink which writes messages to w, with no prefix or log
+// flags, while d is active.
+func (d Debug) To(w io.Writer) Sink {
+	return Sink{Debug: d, Logger: log.New(w, "", 0)}
+}
+
+// ToLogger returns a Sink which writes messages to l while d is active.
+func (d Debug) ToLogger(l *log.Logger) Sink {
+	return Sink{Debug: d, Logger: l}
+}
+
+// Println calls Println on the Sink's Logger if this Sink is active.
+func (s Sink) Println(v ...interface{}) {
+	if s.Debug {
+		s.output()(2, fmt.Sprintln(v...))
+	}
+}
+
+// Printf calls Printf on the Sink's Logger if this Sink is active.
+func (s Sink) Printf(format string, v ...interface{}) {
+	if s.Debug {
+		s.output()(2, fmt.Sprintf(format, v...))
+	}
+}
+
+// Print calls Print on the Sink's Logger if this Sink is active.
+func (s Sink) Print(v ...interface{}) {
+	if s.Debug {
+		s.output()(2, fmt.Sprint(v...))
+	}
+}
+
+func (s Sink) output() outputFunc {
+	if s.Logger == nil {
+		return log.Output
+	}
+	return s.Logger.Output
+}
+
+// On reports whether this Sink is active.
+func (s Sink) On() bool {
+	return s.Debug.On()
+}
+
+// Every is like Debug.Every, returning a Sink with the same Logger.
+func (s Sink) Every(n int) Sink {
+	return Sink{Debug: s.Debug.Every(n), Logger: s.Logger}
+}
+
+// Limit is like Debug.Limit, returning a Sink with the same Logger.
+func (s Sink) Limit(n int, interval time.Duration) Sink {
+	return Sink{Debug: s.Debug.Limit(n, interval), Logger: s.Logger}
+}
+
+// Span is like Debug.Span, writing to the Sink's Logger.
+func (s Sink) Span(name string) func() {
+	if !enabled || !s.Debug {
+		return nop
+	}
+	return span(s.output(), name)
+}
+
+// Dump is like Debug.Dump, writing to the Sink's Logger.
+func (s Sink) Dump(values ...interface{}) {
+	if !enabled || !s.Debug {
+		return
+	}
+	dump(s.output(), values)
+}
+
+// Assert is like Debug.Assert, but when AssertPanics is false, the
+// failure is written to the Sink's Logger.
+func (s Sink) Assert(cond bool, format string, args ...interface{}) {
+	if !enabled || !s.Debug.On() || cond {
+		return
+	}
+	fail(s.output(), fmt.Sprintf(format, args...))
+}
+
+// Invariant is like Debug.Invariant, but when AssertPanics is false,
+// the failure is written to the Sink's Logger.
+func (s Sink) Invariant(check func() error) {
+	if !enabled || !s.Debug {
+		return
+	}
+	if err := check(); err != nil {
+		fail(s.output(), err.Error())
+	}
+}
+
+// A Recorder is an io.Writer which keeps each write in memory, along
+// with the time it was made. Bound to a Debug value with To, it keeps
+// one Entry per message, so tests can check their debugging output
+// without redirecting the default log. The zero value is ready to use.
+type Recorder struct {
+	lock    sync.Mutex
+	entries []Entry
+}
+
+// An Entry is a single message kept by a Recorder.
+type Entry struct {
+	Time    time.Time
+	Message string // Without its trailing newline
+}
+
+// Write records p as a single Entry.
+func (r *Recorder) Write(p []byte) (int, error) {
+	entry := Entry{
+		Time:    time.Now(),
+		Message: strings.TrimSuffix(string(p), "\n"),
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.entries = append(r.entries, entry)
+	return len(p), nil
+}
+
+// Entries returns a copy of the entries recorded so far.
+func (r *Recorder) Entries() []Entry {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]Entry(nil), r.entries...)
+}
+
+// Messages returns the messages recorded so far.
+func (r *Recorder) Messages() []string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	messages := make([]string, len(r.entries))
+	for i, entry := range r.entries {
+		messages[i] = entry.Message
+	}
+	return messages
+}
+
+// Reset discards the entries recorded so far.
+func (r *Recorder) Reset() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.entries = nil
+}
@@ -0,0 +1,129 @@
+package dbg
+
+import (
+	"bytes"
+	"log"
+	"reflect"
+	"strconv"
+	"strings"
+	. "testing"
+	"time"
+)
+
+func TestSink(t *T) {
+	buf := new(bytes.Buffer)
+	s := Debug(true).ToLogger(log.New(buf, "pfx ", 0))
+	s.Println("a", 1)
+	s.Printf("%d", 2)
+	s.Print("b")
+
+	if out, expect := buf.String(), "pfx a 1\npfx 2\npfx b\n"; out != expect {
+		t.Errorf("expected %q, got %q", expect, out)
+	}
+
+	buf.Reset()
+	Debug(false).To(buf).Print("hidden")
+	if buf.Len() != 0 {
+		t.Errorf("expected no output when off, got %q", buf.String())
+	}
+}
+
+func TestRecorder(t *T) {
+	for i := 0; i < 4; i++ {
+		i := i
+		t.Run(strconv.Itoa(i), func(t *T) {
+			t.Parallel()
+
+			rec := new(Recorder)
+			d := Debug(true).To(rec)
+			before := time.Now()
+			for j := 0; j < 3; j++ {
+				d.Println(i, j)
+			}
+
+			expect := []string{
+				strconv.Itoa(i) + " 0",
+				strconv.Itoa(i) + " 1",
+				strconv.Itoa(i) + " 2",
+			}
+			if msgs := rec.Messages(); !reflect.DeepEqual(msgs, expect) {
+				t.Errorf("expected %q, got %q", expect, msgs)
+			}
+			for _, entry := range rec.Entries() {
+				if entry.Time.Before(before) {
+					t.Errorf("entry %q: time %v before start %v", entry.Message, entry.Time, before)
+				}
+			}
+
+			rec.Reset()
+			if n := len(rec.Entries()); n != 0 {
+				t.Errorf("expected no entries after Reset, got %d", n)
+			}
+		})
+	}
+}
+
+func TestSinkMethods(t *T) {
+	if !enabled {
+		t.Skip("built with nodebug")
+	}
+	resetSites()
+
+	rec := new(Recorder)
+	s := Debug(true).To(rec)
+	for i := 0; i < 3; i++ {
+		s.Every(2).Println("every", i)
+		s.Limit(1, time.Hour).Println("limit", i)
+	}
+	n := 42
+	s.Dump(n)
+	s.Span("x")()
+
+	msgs := rec.Messages()
+	expect := []string{"every 0", "limit 0", "every 2", "n = 42", "> x"}
+	if len(msgs) != len(expect)+1 || !reflect.DeepEqual(msgs[:len(expect)], expect) {
+		t.Fatalf("expected %q followed by span exit, got %q", expect, msgs)
+	}
+	if !strings.HasPrefix(msgs[5], "< x (") {
+		t.Errorf("expected span exit, got %q", msgs[5])
+	}
+}
+
+func TestSinkCaller(t *T) {
+	if !enabled {
+		t.Skip("built with nodebug")
+	}
+
+	buf := new(bytes.Buffer)
+	s := Debug(true).ToLogger(log.New(buf, "", log.Lshortfile))
+	s.Println("a")
+	s.Span("b")()
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %q", lines)
+	}
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "sink_test.go:") {
+			t.Errorf("expected caller sink_test.go, got %q", line)
+		}
+	}
+}
+
+func TestSinkZero(t *T) {
+	buf := setupLogger()
+	defer resetLogger()
+
+	// A Sink without a Logger writes to the default log.
+	s := Sink{Debug: true}
+	s.Println("a")
+	s.Span("b")()
+
+	expect := "a\n> b\n< b ("
+	if !enabled {
+		expect = "a\n"
+	}
+	if out := buf.String(); !strings.HasPrefix(out, expect) {
+		t.Errorf("expected %q, got %q", expect, out)
+	}
+}
@@ -0,0 +1,151 @@
+//go:build go1.21
+// +build go1.21
+
+package dbg
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// A Slog is like a Debug value, but writes its messages to a
+// slog.Logger instead of the default log. Messages are written at
+// Level, with the caller of the Slog method as their source, so
+// existing Println, Printf and Print call sites of a Debug value can be
+// moved over to slog unchanged.
+type Slog struct {
+	Debug  Debug        // Whether messages are written
+	Logger *slog.Logger // If nil, slog.Default() is used.
+	Level  slog.Level
+}
+
+// On reports whether this Slog is active.
+func (s *Slog) On() bool {
+	return s.Debug.On()
+}
+
+// NewSlog creates a Slog which writes messages to h at slog.LevelDebug.
+// It starts out on or off along with Default.
+func NewSlog(h slog.Handler) *Slog {
+	return &Slog{
+		Debug:  Default,
+		Logger: slog.New(h),
+		Level:  slog.LevelDebug,
+	}
+}
+
+// With returns a copy of s which adds the given attributes to each
+// message, as with slog.Logger.With.
+func (s *Slog) With(args ...interface{}) *Slog {
+	c := *s
+	c.Logger = s.logger().With(args...)
+	return &c
+}
+
+// Println formats the arguments as fmt.Sprintln does, and logs the
+// result if this Slog is active.
+func (s *Slog) Println(v ...interface{}) {
+	if s.Debug {
+		s.output(strings.TrimSuffix(fmt.Sprintln(v...), "\n"), nil)
+	}
+}
+
+// Printf formats the arguments as fmt.Sprintf does, and logs the
+// result if this Slog is active.
+func (s *Slog) Printf(format string, v ...interface{}) {
+	if s.Debug {
+		s.output(fmt.Sprintf(format, v...), nil)
+	}
+}
+
+// Print formats the arguments as fmt.Sprint does, and logs the result
+// if this Slog is active.
+func (s *Slog) Print(v ...interface{}) {
+	if s.Debug {
+		s.output(fmt.Sprint(v...), nil)
+	}
+}
+
+// Log logs msg with the given attributes, as with slog.Logger.Log, if
+// this Slog is active.
+func (s *Slog) Log(msg string, args ...interface{}) {
+	if s.Debug {
+		s.output(msg, args)
+	}
+}
+
+func (s *Slog) logger() *slog.Logger {
+	if s.Logger == nil {
+		return slog.Default()
+	}
+	return s.Logger
+}
+
+// output writes a record to the Logger's handler. It must be called
+// directly from the exported method the user called, so the record's
+// source is the right caller.
+func (s *Slog) output(msg string, args []interface{}) {
+	ctx := context.Background()
+	h := s.logger().Handler()
+	if !h.Enabled(ctx, s.Level) {
+		return
+	}
+
+	// Skip runtime.Callers, output, and the exported method.
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:])
+
+	r := slog.NewRecord(time.Now(), s.Level, msg, pcs[0])
+	r.Add(args...)
+	h.Handle(ctx, r)
+}
+
+// A Handler is a slog.Handler which passes records on to another
+// Handler only while debugging is on. It is always off when built with
+// the nodebug build flag, and otherwise follows the switch it was
+// created with, such as a Debug value or a *Channel.
+type Handler struct {
+	handler slog.Handler
+	debug   interface{ On() bool }
+}
+
+// NewHandler creates a Handler which passes records on to h while d is
+// on. Given a *Channel, the Handler follows it as it is switched at
+// runtime. If d is nil, only the nodebug build flag is checked.
+func NewHandler(h slog.Handler, d interface{ On() bool }) *Handler {
+	return &Handler{handler: h, debug: d}
+}
+
+func (h *Handler) on() bool {
+	return enabled && (h.debug == nil || h.debug.On())
+}
+
+// Enabled reports whether debugging is on, and the wrapped handler is
+// enabled for level.
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.on() && h.handler.Enabled(ctx, level)
+}
+
+// Handle passes r on to the wrapped handler if debugging is on.
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	if !h.on() {
+		return nil
+	}
+	return h.handler.Handle(ctx, r)
+}
+
+// WithAttrs returns a Handler wrapping the result of WithAttrs on the
+// wrapped handler, following the same switch.
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &Handler{handler: h.handler.WithAttrs(attrs), debug: h.debug}
+}
+
+// WithGroup returns a Handler wrapping the result of WithGroup on the
+// wrapped handler, following the same switch.
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{handler: h.handler.WithGroup(name), debug: h.debug}
+}
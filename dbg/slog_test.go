@@ -0,0 +1,89 @@
+//go:build go1.21
+// +build go1.21
+
+package dbg
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	. "testing"
+)
+
+// newTextHandler returns a text handler writing to a buffer, without
+// timestamps, and with only the base name of source files.
+func newTextHandler(buf *bytes.Buffer) slog.Handler {
+	return slog.NewTextHandler(buf, &slog.HandlerOptions{
+		AddSource: true,
+		Level:     slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			switch a.Key {
+			case slog.TimeKey:
+				return slog.Attr{}
+			case slog.SourceKey:
+				src := a.Value.Any().(*slog.Source)
+				return slog.String(a.Key, filepath.Base(src.File))
+			}
+			return a
+		},
+	})
+}
+
+func TestSlog(t *T) {
+	buf := new(bytes.Buffer)
+	s := NewSlog(newTextHandler(buf))
+	s.Debug = true
+
+	s.Println("a", 1)
+	s.Printf("%d%s", 2, "b")
+	s.With("req", 7).Log("done", "ok", true)
+
+	expect := "level=DEBUG source=slog_test.go msg=\"a 1\"\n" +
+		"level=DEBUG source=slog_test.go msg=2b\n" +
+		"level=DEBUG source=slog_test.go msg=done req=7 ok=true\n"
+	if out := buf.String(); out != expect {
+		t.Errorf("expected:\n%s\ngot:\n%s", expect, out)
+	}
+
+	buf.Reset()
+	s.Debug = false
+	s.Print("hidden")
+	if buf.Len() != 0 {
+		t.Errorf("expected no output when off, got %q", buf.String())
+	}
+}
+
+func TestHandler(t *T) {
+	buf := new(bytes.Buffer)
+	c := New("test.slog.handler")
+	c.Set(true)
+	l := slog.New(NewHandler(newTextHandler(buf), c)).With("a", 1).WithGroup("g")
+
+	l.Info("shown", "b", 2)
+	expect := "level=INFO source=slog_test.go msg=shown a=1 g.b=2\n"
+	if !enabled {
+		expect = ""
+	}
+	if out := buf.String(); out != expect {
+		t.Errorf("expected %q, got %q", expect, out)
+	}
+
+	buf.Reset()
+	c.Set(false)
+	l.Info("hidden")
+	if buf.Len() != 0 {
+		t.Errorf("expected no output when off, got %q", buf.String())
+	}
+
+	if h := NewHandler(newTextHandler(buf), Debug(true)); h.Enabled(context.Background(), slog.LevelInfo) != enabled {
+		t.Errorf("Debug(true): expected Enabled to follow the build flag (%v)", enabled)
+	}
+	if h := NewHandler(newTextHandler(buf), nil); h.Enabled(context.Background(), slog.LevelInfo) != enabled {
+		t.Errorf("nil Debug: expected Enabled to follow the build flag (%v)", enabled)
+	}
+	if strings.Contains(buf.String(), "hidden") {
+		t.Errorf("unexpected output %q", buf.String())
+	}
+}
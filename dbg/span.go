@@ -0,0 +1,74 @@
+package dbg
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// depths holds the number of open spans for each goroutine, so that
+// nested spans are indented.
+var depths = struct {
+	sync.Mutex
+	m map[uint64]int
+}{m: map[uint64]int{}}
+
+func nop() {}
+
+// An outputFunc writes a message, as log.Output and log.Logger's Output
+// method do.
+type outputFunc func(calldepth int, s string) error
+
+// Span logs the entry to a section of code called name, if this Debug
+// is active, and returns a function which logs the exit from it, along
+// with the time spent. It is meant to be deferred:
+//
+//	defer debug.Span("load")()
+//
+// Spans opened inside other spans on the same goroutine are indented
+// by their depth:
+//
+//	> load
+//	  > parse
+//	  < parse (1.2ms)
+//	< load (3.4ms)
+//
+// When built with the nodebug build flag, Span does nothing.
+func (d Debug) Span(name string) func() {
+	if !enabled || !d {
+		return nop
+	}
+	return span(log.Output, name)
+}
+
+// span logs through output the entry to the section called name, and
+// returns the function which logs the exit from it. It must be called
+// directly from the exported method the user called, to get the right
+// caller for log.Lshortfile.
+func span(output outputFunc, name string) func() {
+	id := goroutineID()
+	depths.Lock()
+	depth := depths.m[id]
+	depths.m[id] = depth + 1
+	depths.Unlock()
+
+	indent := strings.Repeat("  ", depth)
+	output(3, fmt.Sprintf("%s> %s", indent, name))
+	begin := time.Now()
+
+	return func() {
+		elapsed := time.Since(begin)
+
+		depths.Lock()
+		if depth == 0 {
+			delete(depths.m, id)
+		} else {
+			depths.m[id] = depth
+		}
+		depths.Unlock()
+
+		output(2, fmt.Sprintf("%s< %s (%v)", indent, name, elapsed))
+	}
+}
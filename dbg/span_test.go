@@ -0,0 +1,39 @@
+package dbg
+
+import (
+	"regexp"
+	"strings"
+	. "testing"
+)
+
+func TestSpan(t *T) {
+	rec := new(Recorder)
+	d := Debug(true).To(rec)
+	func() {
+		defer d.Span("outer")()
+		func() {
+			defer d.Span("inner")()
+		}()
+	}()
+
+	expect := `^> outer\n  > inner\n  < inner \(\S+\)\n< outer \(\S+\)$`
+	if !enabled {
+		expect = `^$`
+	}
+	if out := strings.Join(rec.Messages(), "\n"); !regexp.MustCompile(expect).MatchString(out) {
+		t.Errorf("expected match for %q, got %q", expect, out)
+	}
+
+	// Depth is back to zero once all spans are closed.
+	rec.Reset()
+	d.Span("again")()
+	if out := strings.Join(rec.Messages(), "\n"); enabled && !regexp.MustCompile(`^> again\n< again`).MatchString(out) {
+		t.Errorf("expected unindented span, got %q", out)
+	}
+
+	rec.Reset()
+	Debug(false).To(rec).Span("hidden")()
+	if msgs := rec.Messages(); len(msgs) != 0 {
+		t.Errorf("expected no output when off, got %q", msgs)
+	}
+}